@@ -1,55 +1,132 @@
 package main
 
 import (
-	"bookstore/health"
 	"bookstore/internal/api"
 	"bookstore/internal/application"
 	"bookstore/internal/application/config"
+	"bookstore/internal/gql"
+	"bookstore/internal/grpcapi"
+	"bookstore/internal/migrations"
+	"bookstore/internal/tracing"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"os"
 
-	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
 )
 
 func main() {
-	config, err := config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal(slog.Default(), err)
+	}
+	if len(args) > 0 && args[0] == "print-config" {
+		if err := cfg.Write(os.Stdout); err != nil {
+			fatal(slog.Default(), err)
+		}
+		return
+	}
+
+	app, err := application.Load(cfg)
+	if err != nil {
+		fatal(slog.Default(), err)
+	}
+	// Code without access to the application, such as libraries, logs and
+	// traces through the global defaults.
+	slog.SetDefault(app.Logger())
+	otel.SetTracerProvider(app.TracerProvider())
+	otel.SetTextMapPropagator(tracing.Propagator)
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if app.DB() == nil {
+			fatal(app.Logger(), errors.New("migrate needs STORAGE=postgres"))
+		}
+		err := runMigrate(context.Background(), app.DB(), args[1:])
+		app.Shutdown(context.Background())
+		if err != nil {
+			fatal(app.Logger(), err)
+		}
+		return
+	}
 
-	//app, err := application.Load()
+	service, err := newService(context.Background(), app)
 	if err != nil {
-		panic(err)
+		app.Shutdown(context.Background())
+		fatal(app.Logger(), err)
 	}
-	app := application.NewAppMock()
-	r := setupRouter(app, config)
-	if err := r.Run(fmt.Sprintf(":%d", config.AppPort)); err != nil {
-		panic(err)
+	r := api.NewRouter(app, service)
+	gql.Register(r, app, service)
+	app.Handle(r)
+	app.ServeGRPC(grpcapi.NewServer(app, service))
+
+	if err := app.Run(context.Background()); err != nil {
+		fatal(app.Logger(), err)
 	}
+}
+
+func fatal(logger *slog.Logger, err error) {
+	logger.Error(err.Error())
+	os.Exit(1)
+}
 
+// newService wires the instrumented repository selected by STORAGE into
+// the service, creating the ADMIN_EMAIL account first when one is set.
+func newService(ctx context.Context, app *application.Application) (api.Service, error) {
+	repo, err := newRepository(app)
+	if err != nil {
+		return nil, err
+	}
+	tp := app.TracerProvider()
+	repo = api.NewInstrumentedRepository(repo, app.Metrics(), tp)
+	service := api.NewTracedService(api.NewService(app, repo), tp)
+	if email := app.Config().AdminEmail; email != "" {
+		if err := seedAdmin(ctx, service, repo, email, app.Config().AdminPassword); err != nil {
+			return nil, fmt.Errorf("failed to create the admin account: %w", err)
+		}
+		app.Logger().Info("Created the admin account", "email", email)
+	}
+	return service, nil
 }
 
-func setupRouter(app *application.Application, config *config.Config) *gin.Engine {
-	r := gin.New()
-	r.Use(gin.Recovery())
-
-	// db, err := api.NewPostgresDB(&config.Config{
-	// 	DBHost:     "localhost",
-	// 	DBPort:     5432,
-	// 	DBUser:     "postgres",
-	// 	DBPassword: "admin",
-	// 	DBName:     "bookstore",
-	// })
-	db, err := api.NewPostgresDB(config)
+// seedAdmin creates an account through the service, so that the password
+// is hashed like any other, and makes it an admin.
+func seedAdmin(ctx context.Context, service api.Service, repo api.Repository, email, password string) error {
+	if !api.PasswordAllowed(password) {
+		return errors.New("ADMIN_PASSWORD must be 8 to 72 bytes and contain a letter and a digit")
+	}
+	if err := service.CreateAccount(ctx, email, password); err != nil {
+		return err
+	}
+	id, err := repo.GetUserIDByEmail(ctx, email)
 	if err != nil {
-		panic(err)
-	}
-	bookstoreRepo := api.NewRepository(app, *db)
-	bookStoreService := api.NewService(app, bookstoreRepo)
-	bookStoreHandler := api.NewHandler(app, bookStoreService)
-	r.GET("/health", health.Check)
-	r.GET("/books", bookStoreHandler.GetAllBooks)
-	r.POST("/accounts", bookStoreHandler.CreateAccount)
-	r.POST("/orders", bookStoreHandler.PlaceOrder)
-	r.GET("/order/history", bookStoreHandler.GetOrderHistory)
-	r.GET("/users/:email", bookStoreHandler.GetUserIDByEmail)
-	r.GET("/book/", bookStoreHandler.GetBookByID)
-	return r
+		return err
+	}
+	return repo.SetUserRole(ctx, id, api.RoleAdmin)
+}
 
+// newRepository returns the repository selected by STORAGE. The Postgres
+// one is migrated first when DB_AUTO_MIGRATE is set, and the schema version
+// becomes a readiness check.
+func newRepository(app *application.Application) (api.Repository, error) {
+	if app.Config().Storage == config.StorageMemory {
+		app.Logger().Warn("Keeping data in memory; it is lost on exit")
+		return api.NewMemoryRepository(app), nil
+	}
+	m, err := migrations.New(app.DB())
+	if err != nil {
+		return nil, err
+	}
+	if app.Config().AutoMigrate {
+		if err := m.Up(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	app.Health().Register("migrations", 0, m.Check)
+	return api.NewRepository(app, *api.NewPostgresDB(app.DB())), nil
 }
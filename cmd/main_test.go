@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	"bookstore/internal/application/config"
+	"bookstore/internal/logging"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_MemoryStorage runs a shop with memory storage from an empty store:
+// the seeded admin fills the catalog and a new customer orders from it.
+func Test_MemoryStorage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.AdminEmail = "admin@example.com"
+	cfg.AdminPassword = "admin-passw0rd"
+	cfg.BcryptCost = 4
+	require.NoError(t, cfg.Validate())
+	app := application.New(cfg, nil, logging.Discard())
+
+	service, err := newService(context.Background(), app)
+	require.NoError(t, err)
+	server := httptest.NewServer(api.NewRouter(app, service))
+	t.Cleanup(server.Close)
+
+	admin := login(t, server, "admin@example.com", "admin-passw0rd")
+	var book struct{ ID string }
+	do(t, server, admin, http.MethodPost, "/api/v1/books",
+		`{"title": "Dune", "author": "Frank Herbert", "price": "9.99", "stock": 3}`, http.StatusCreated, &book)
+	require.NotEmpty(t, book.ID)
+
+	do(t, server, "", http.MethodPost, "/api/v1/accounts",
+		`{"email": "ann@example.com", "password": "ann-passw0rd"}`, http.StatusCreated, nil)
+	ann := login(t, server, "ann@example.com", "ann-passw0rd")
+	do(t, server, ann, http.MethodPost, "/api/v1/books",
+		`{"title": "Emma", "author": "Jane Austen", "price": "5.00", "stock": 1}`, http.StatusForbidden, nil)
+
+	var order struct {
+		Status string
+		Total  string
+	}
+	do(t, server, ann, http.MethodPost, "/api/v1/orders",
+		`{"items": [{"bookId": "`+book.ID+`", "quantity": 2}]}`, http.StatusCreated, &order)
+	assert.Equal(t, "pending", order.Status)
+	assert.Equal(t, "19.98", order.Total)
+
+	var got struct{ Book struct{ Stock int } }
+	do(t, server, "", http.MethodGet, "/api/v1/books/"+book.ID, "", http.StatusOK, &got)
+	assert.Equal(t, 1, got.Book.Stock)
+}
+
+func Test_NewService_AdminPasswordPolicy(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.AdminEmail = "admin@example.com"
+	cfg.AdminPassword = "short"
+	cfg.BcryptCost = 4
+	app := application.New(cfg, nil, logging.Discard())
+
+	_, err := newService(context.Background(), app)
+
+	assert.ErrorContains(t, err, "ADMIN_PASSWORD must be 8 to 72 bytes")
+}
+
+func login(t *testing.T, server *httptest.Server, email, password string) string {
+	t.Helper()
+	var session struct{ Token string }
+	do(t, server, "", http.MethodPost, "/api/v1/sessions",
+		`{"email": "`+email+`", "password": "`+password+`"}`, http.StatusCreated, &session)
+	require.NotEmpty(t, session.Token)
+	return session.Token
+}
+
+// do sends body to path with the session token, when given, checks the
+// status and decodes the response into out, when given.
+func do(t *testing.T, server *httptest.Server, token, method, path, body string, status int, out any) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, status, resp.StatusCode, "%s %s", method, path)
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+}
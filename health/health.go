@@ -0,0 +1,146 @@
+package health
+
+import (
+	"bookstore/internal/logging"
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTimeout bounds a check registered without a timeout of its own.
+const DefaultTimeout = 2 * time.Second
+
+// Report statuses.
+const (
+	StatusOK           = "ok"
+	StatusFailing      = "failing"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc probes a dependency, returning an error when it is not usable.
+// It must return once ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// Report is the JSON body of the probe endpoints.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of one check. Error is logged by Readiness
+// but not served, since the probes are unauthenticated.
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"-"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Registry holds the readiness checks of the application and whether it is
+// shutting down.
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a readiness check. A timeout of 0 means DefaultTimeout.
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+// SetShuttingDown makes readiness fail from now on, so that load balancers
+// stop routing new requests while in-flight ones drain.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Ready runs every check concurrently, each under its own timeout.
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+func (c check) run(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.fn(ctx)
+	result := CheckResult{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Live answers the liveness probe: the process is up and serving requests.
+// It does not consult the checks, so that a database outage does not get
+// every instance restarted.
+func (r *Registry) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readiness answers the readiness probe with the status of every check,
+// and 503 when one of them fails or the application is shutting down. The
+// errors of failing checks are logged.
+func (r *Registry) Readiness(c *gin.Context) {
+	ctx := c.Request.Context()
+	report := r.Ready(ctx)
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	for name, result := range report.Checks {
+		if result.Error != "" {
+			logging.FromContext(ctx).WarnContext(ctx, "readiness check failed", "check", name, "error", result.Error)
+		}
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,152 @@
+package health_test
+
+import (
+	"bookstore/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, registry *health.Registry, path string) (int, health.Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", registry.Live)
+	r.GET("/readyz", registry.Readiness)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	r.ServeHTTP(w, req)
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func hanging(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]health.CheckFunc
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+		wantErrors map[string]string
+	}{
+		{
+			name:       "no_checks",
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusOK,
+		},
+		{
+			name:       "all_pass",
+			checks:     map[string]health.CheckFunc{"database": ok, "migrations": ok},
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusOK,
+			wantChecks: map[string]string{"database": health.StatusOK, "migrations": health.StatusOK},
+		},
+		{
+			name:       "one_fails",
+			checks:     map[string]health.CheckFunc{"database": failing, "migrations": ok},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusUnavailable,
+			wantChecks: map[string]string{"database": health.StatusFailing, "migrations": health.StatusOK},
+			wantErrors: map[string]string{"database": "connection refused"},
+		},
+		{
+			name:       "one_times_out",
+			checks:     map[string]health.CheckFunc{"database": hanging},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusUnavailable,
+			wantChecks: map[string]string{"database": health.StatusFailing},
+			wantErrors: map[string]string{"database": "context deadline exceeded"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry()
+			for name, fn := range tt.checks {
+				registry.Register(name, 20*time.Millisecond, fn)
+			}
+
+			code, report := serve(t, registry, "/readyz")
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, report.Status)
+			require.Len(t, report.Checks, len(tt.wantChecks))
+			for name, status := range tt.wantChecks {
+				assert.Equal(t, status, report.Checks[name].Status, name)
+			}
+			for name, result := range registry.Ready(context.Background()).Checks {
+				assert.Equal(t, tt.wantErrors[name], result.Error, name)
+			}
+		})
+	}
+}
+
+func Test_Readiness_HidesErrors(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("database", 0, failing)
+	r := gin.New()
+	r.GET("/readyz", registry.Readiness)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unavailable","checks":{"database":{"status":"failing","durationMs":0}}}`, w.Body.String())
+}
+
+func Test_Readiness_ChecksRunConcurrently(t *testing.T) {
+	registry := health.NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		registry.Register(name, 100*time.Millisecond, hanging)
+	}
+
+	start := time.Now()
+	code, _ := serve(t, registry, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+}
+
+func Test_Readiness_FailsWhenShuttingDown(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("database", 0, ok)
+
+	registry.SetShuttingDown()
+	code, report := serve(t, registry, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+}
+
+func Test_Liveness_IgnoresChecks(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("database", 0, failing)
+	registry.SetShuttingDown()
+
+	code, report := serve(t, registry, "/healthz")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Empty(t, report.Checks)
+}
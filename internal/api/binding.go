@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerValidatorsOnce sync.Once
+
+// registerValidators adds the custom tags used in `binding` struct tags to
+// gin's validator and makes it report fields by their JSON or query name.
+func registerValidators() {
+	registerValidatorsOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("api: gin binding is not using go-playground/validator")
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, key := range []string{"json", "form", "uri"} {
+				name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+				if name != "" && name != "-" {
+					return name
+				}
+			}
+			return f.Name
+		})
+		mustRegister(v, "id", func(fl validator.FieldLevel) bool {
+			return validateID("", fl.Field().String()) == nil
+		})
+		mustRegister(v, "password", func(fl validator.FieldLevel) bool {
+			return PasswordAllowed(fl.Field().String())
+		})
+		mustRegister(v, "max_items", func(fl validator.FieldLevel) bool {
+			return fl.Field().Len() <= maxOrderItems
+		})
+		mustRegister(v, "max_quantity", func(fl validator.FieldLevel) bool {
+			return fl.Field().Int() <= maxItemQuantity
+		})
+		mustRegister(v, "unique_books", func(fl validator.FieldLevel) bool {
+			items, ok := fl.Field().Interface().([]OrderItemRequest)
+			if !ok {
+				return false
+			}
+			seen := make(map[string]bool, len(items))
+			for _, item := range items {
+				if seen[item.BookID] {
+					return false
+				}
+				seen[item.BookID] = true
+			}
+			return true
+		})
+	})
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// bindJSON decodes and validates the request body into obj. On failure it
+// records the error for ErrorHandler and reports false.
+func bindJSON(c *gin.Context, obj any) bool {
+	return bindError(c, c.ShouldBindJSON(obj))
+}
+
+// bindQuery is bindJSON for the query string.
+func bindQuery(c *gin.Context, obj any) bool {
+	return bindError(c, c.ShouldBindQuery(obj))
+}
+
+// bindURI is bindJSON for the path parameters.
+func bindURI(c *gin.Context, obj any) bool {
+	return bindError(c, c.ShouldBindUri(obj))
+}
+
+func bindError(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		c.Error(toValidationErrors(fieldErrs))
+	} else {
+		c.Error(ErrInvalidBody.Wrap(err))
+	}
+	return false
+}
+
+// Validate checks obj against its `binding` tags, as the handlers do for
+// request bodies, for callers that do not decode requests with gin. Failures
+// are reported as ValidationErrors.
+func Validate(obj any) error {
+	registerValidators()
+	err := binding.Validator.ValidateStruct(obj)
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return toValidationErrors(fieldErrs)
+	}
+	return err
+}
+
+func toValidationErrors(fieldErrs validator.ValidationErrors) ValidationErrors {
+	errs := make(ValidationErrors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		// Drop the leading struct name: "OrderRequest.items[0].quantity".
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		errs = append(errs, ValidationError{field, fieldMessage(fe)})
+	}
+	return errs
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "id":
+		return "must be a positive integer"
+	case "password":
+		return fmt.Sprintf("must be %d to %d bytes and contain a letter and a digit", minPasswordBytes, maxPasswordBytes)
+	case "unique_books":
+		return "must not contain the same book twice"
+	case "max_items":
+		return fmt.Sprintf("must have at most %d items", maxOrderItems)
+	case "max_quantity":
+		return fmt.Sprintf("must be at most %d", maxItemQuantity)
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.Slice:
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	}
+	return "is invalid"
+}
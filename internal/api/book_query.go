@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DefaultBookPageSize = 20
+	MaxBookPageSize     = 100
+)
+
+// BookSort names a column the catalog can be ordered by.
+type BookSort string
+
+const (
+	SortByTitle   BookSort = "title"
+	SortByAuthor  BookSort = "author"
+	SortByPrice   BookSort = "price"
+	SortByCreated BookSort = "created"
+)
+
+func (s BookSort) Valid() bool {
+	switch s {
+	case SortByTitle, SortByAuthor, SortByPrice, SortByCreated:
+		return true
+	}
+	return false
+}
+
+// BookQuery selects one page of the catalog. The zero value lists the first
+// page sorted by title.
+type BookQuery struct {
+	Sort     BookSort
+	Desc     bool
+	Author   string
+	MinPrice *Money
+	MaxPrice *Money
+	// InStock keeps only books with (true) or without (false) stock.
+	InStock *bool
+	Cursor  string
+	Limit   int
+
+	// After is the decoded Cursor, set by Normalize.
+	After *BookCursor
+}
+
+// BookPage is one page of a catalog listing. NextCursor is empty on the last
+// page; Total counts every book matching the filters.
+type BookPage struct {
+	Items      []Book
+	NextCursor string
+	Total      int
+}
+
+// BookCursor records the position of the last book on a page: its ID and the
+// value of the sort column. It carries the sort it was produced for so a
+// cursor cannot be replayed against another ordering.
+type BookCursor struct {
+	Sort  BookSort `json:"s"`
+	Desc  bool     `json:"d,omitempty"`
+	Value string   `json:"v"`
+	ID    int64    `json:"id"`
+}
+
+func (c BookCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBookCursor decodes a cursor produced by Encode. Clients can tamper
+// with cursors, so the value is checked to be one the sort column's type can
+// hold before it reaches a query.
+func decodeBookCursor(s string) (BookCursor, error) {
+	var c BookCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID <= 0 || !validCursorValue(c.Sort, c.Value) {
+		return BookCursor{}, ValidationError{"cursor", "is invalid"}
+	}
+	return c, nil
+}
+
+func validCursorValue(sort BookSort, v string) bool {
+	switch sort {
+	case SortByPrice:
+		_, err := strconv.ParseInt(v, 10, 64)
+		return err == nil
+	case SortByCreated:
+		_, err := time.Parse(time.RFC3339Nano, v)
+		return err == nil
+	case SortByTitle, SortByAuthor:
+		// Postgres text cannot hold NUL.
+		return utf8.ValidString(v) && !strings.ContainsRune(v, 0)
+	}
+	return false
+}
+
+// ParseBookSort parses a sort parameter such as "price" or "-created", where a
+// leading "-" selects descending order.
+func ParseBookSort(s string) (BookSort, bool, error) {
+	if s == "" {
+		return SortByTitle, false, nil
+	}
+	field, desc := strings.CutPrefix(s, "-")
+	sort := BookSort(field)
+	if !sort.Valid() {
+		return "", false, ValidationError{"sort", "must be one of title, author, price or created"}
+	}
+	return sort, desc, nil
+}
+
+// Normalize applies defaults, validates the filters and decodes the cursor.
+func (q *BookQuery) Normalize() error {
+	if q.Sort == "" {
+		q.Sort = SortByTitle
+	}
+	if !q.Sort.Valid() {
+		return ValidationError{"sort", "must be one of title, author, price or created"}
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultBookPageSize
+	case q.Limit < 0 || q.Limit > MaxBookPageSize:
+		return ValidationError{"limit", fmt.Sprintf("must be between 1 and %d", MaxBookPageSize)}
+	}
+	if q.MinPrice != nil && *q.MinPrice < 0 {
+		return ValidationError{"min_price", "must not be negative"}
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MaxPrice < *q.MinPrice {
+		return ValidationError{"max_price", "must not be less than min_price"}
+	}
+	q.After = nil
+	if q.Cursor != "" {
+		c, err := decodeBookCursor(q.Cursor)
+		if err != nil {
+			return err
+		}
+		if c.Sort != q.Sort || c.Desc != q.Desc {
+			return ValidationError{"cursor", "does not match the requested sort"}
+		}
+		q.After = &c
+	}
+	return nil
+}
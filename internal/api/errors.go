@@ -0,0 +1,66 @@
+package api
+
+import (
+	"bookstore/internal/apperror"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrBookNotFound  = apperror.NotFound("book_not_found", "book not found")
+	ErrBookInUse     = apperror.Conflict("book_in_use", "book is referenced by existing orders")
+	ErrCartEmpty     = apperror.Validation("cart_empty", "cart is empty")
+	ErrOrderNotFound = apperror.NotFound("order_not_found", "order not found")
+	// ErrOrderStatusConflict means the order changed status concurrently
+	// and the transition should be retried against the new status.
+	ErrOrderStatusConflict = apperror.Conflict("order_status_conflict", "order status was changed concurrently")
+	ErrUserNotFound        = apperror.NotFound("user_not_found", "user not found")
+	ErrEmailTaken          = apperror.Conflict("email_taken", "an account with this email already exists")
+	ErrInvalidBody         = apperror.BadRequest("invalid_body", "invalid request body")
+	ErrAuthRequired        = apperror.Unauthorized("authentication_required", "authentication required")
+	ErrInvalidSession      = apperror.Unauthorized("invalid_session", "invalid or expired session")
+	ErrForbidden           = apperror.Forbidden("insufficient_permissions", "insufficient permissions")
+)
+
+// InsufficientStockError rejects an order because some books do not have
+// enough copies left. BookIDs lists every offending book, not just the first.
+type InsufficientStockError struct {
+	BookIDs []string
+}
+
+func (e *InsufficientStockError) Error() string {
+	return "insufficient stock for books: " + strings.Join(e.BookIDs, ", ")
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return apperror.Conflict("insufficient_stock", "insufficient stock").With("bookIds", e.BookIDs)
+}
+
+// PriceChangedError rejects a checkout because the catalog price of some
+// books changed since they were put in the cart.
+type PriceChangedError struct {
+	BookIDs []string
+}
+
+func (e *PriceChangedError) Error() string {
+	return "prices changed for books: " + strings.Join(e.BookIDs, ", ")
+}
+
+func (e *PriceChangedError) Unwrap() error {
+	return apperror.Conflict("price_changed", "cart prices changed, review the cart and retry").With("bookIds", e.BookIDs)
+}
+
+// InvalidTransitionError rejects a status change that the order lifecycle
+// does not allow.
+type InvalidTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+func (e *InvalidTransitionError) Unwrap() error {
+	return apperror.Conflict("invalid_transition", e.Error())
+}
@@ -2,28 +2,44 @@ package api
 
 import (
 	"bookstore/internal/application"
-	"log"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler interface {
 	GetAllBooks(c *gin.Context)
+	SearchBooks(c *gin.Context)
 	PlaceOrder(c *gin.Context)
 	GetOrderHistory(c *gin.Context)
+	GetLegacyOrderHistory(c *gin.Context)
 	CreateAccount(c *gin.Context)
 	GetUserIDByEmail(c *gin.Context)
 	GetBookByID(c *gin.Context)
+	CreateSession(c *gin.Context)
+	CreateBook(c *gin.Context)
+	UpdateBook(c *gin.Context)
+	PatchBook(c *gin.Context)
+	DeleteBook(c *gin.Context)
+	GetCart(c *gin.Context)
+	ReplaceCart(c *gin.Context)
+	AddCartItem(c *gin.Context)
+	RemoveCartItem(c *gin.Context)
+	CheckoutCart(c *gin.Context)
+	CancelOrder(c *gin.Context)
+	TransitionOrder(c *gin.Context)
+	GetDatabaseStats(c *gin.Context)
 }
 
 type handler struct {
 	app     *application.Application
 	service Service
-	// logger  *logrus.Logger
 }
 
 func NewHandler(app *application.Application, service Service) Handler {
+	registerValidators()
 	return &handler{
 		app:     app,
 		service: service,
@@ -31,57 +47,113 @@ func NewHandler(app *application.Application, service Service) Handler {
 }
 
 func (h handler) GetAllBooks(c *gin.Context) {
-	books, err := h.service.GetAllBooks(c.Request.Context())
+	query, err := bookQueryFrom(c)
 	if err != nil {
-		log.Printf("Error fetching books: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch the books"})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, books)
+	books, err := h.service.GetAllBooks(c.Request.Context(), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newBookPageView(books))
 }
 
-func (h handler) CreateAccount(c *gin.Context) {
-	var user User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		log.Printf("Invalid request body for creating account: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
-		return
+func (h handler) SearchBooks(c *gin.Context) {
+	search := BookSearch{Query: c.Query("q")}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.Error(ValidationError{"limit", fmt.Sprintf("must be between 1 and %d", MaxSearchLimit)})
+			return
+		}
+		search.Limit = limit
 	}
-	if user.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+	results, err := h.service.SearchBooks(c.Request.Context(), search)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	if user.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+	c.JSON(http.StatusOK, newBookSearchView(results))
+}
+
+// bookQueryFrom reads the listing parameters sort, author, min_price,
+// max_price, in_stock, cursor and limit from the query string.
+func bookQueryFrom(c *gin.Context) (BookQuery, error) {
+	var query BookQuery
+	var err error
+	if query.Sort, query.Desc, err = ParseBookSort(c.Query("sort")); err != nil {
+		return BookQuery{}, err
+	}
+	query.Author = c.Query("author")
+	query.Cursor = c.Query("cursor")
+	for field, dst := range map[string]**Money{"min_price": &query.MinPrice, "max_price": &query.MaxPrice} {
+		if v := c.Query(field); v != "" {
+			price, err := ParseMoney(v)
+			if err != nil {
+				return BookQuery{}, ValidationError{field, "must be an amount such as 12.34"}
+			}
+			*dst = &price
+		}
+	}
+	if v := c.Query("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return BookQuery{}, ValidationError{"in_stock", "must be true or false"}
+		}
+		query.InStock = &inStock
+	}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit <= 0 {
+			return BookQuery{}, ValidationError{"limit", fmt.Sprintf("must be between 1 and %d", MaxBookPageSize)}
+		}
+	}
+	return query, nil
+}
+
+func (h handler) CreateAccount(c *gin.Context) {
+	var user AccountRequest
+	if !bindJSON(c, &user) {
 		return
 	}
 	if err := h.service.CreateAccount(c.Request.Context(), user.Email, user.Password); err != nil {
-		log.Printf("Error creating account: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, "created")
 }
 
-func (h handler) GetOrderHistory(c *gin.Context) {
-
-	email := c.Query("email")
-	if email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email parameter is required"})
+func (h handler) CreateSession(c *gin.Context) {
+	var credentials LoginRequest
+	if !bindJSON(c, &credentials) {
+		return
+	}
+	session, err := h.service.CreateSession(c.Request.Context(), credentials.Email, credentials.Password)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusCreated, newSessionView(session))
+}
 
-	userID, err := h.service.GetUserIDByEmail(c.Request.Context(), email)
+func (h handler) GetOrderHistory(c *gin.Context) {
+	orders, err := h.service.GetOrderHistory(c.Request.Context(), authenticatedUserID(c))
 	if err != nil {
-		log.Printf("Error getting user ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user ID"})
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusOK, newOrderViews(orders))
+}
+
+// GetLegacyOrderHistory serves /order/history, which answers an empty
+// history with a message instead of an empty list.
+func (h handler) GetLegacyOrderHistory(c *gin.Context) {
+	userID := authenticatedUserID(c)
 
 	orders, err := h.service.GetOrderHistory(c.Request.Context(), userID)
 	if err != nil {
-		log.Printf("Error fetching order history: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -90,78 +162,116 @@ func (h handler) GetOrderHistory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, newOrderViews(orders))
 }
 
 func (h handler) PlaceOrder(c *gin.Context) {
-	var orderRequest Order
-	if err := c.ShouldBindJSON(&orderRequest); err != nil {
-		log.Printf("Invalid request body for placing order: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	var orderRequest OrderRequest
+	if !bindJSON(c, &orderRequest) {
 		return
 	}
 
-	email := c.Query("email")
-	if email == "" {
-		c.JSON(http.StatusBadRequest, "email parameter is required")
+	userID := authenticatedUserID(c)
+
+	order, err := h.service.PlaceOrder(c.Request.Context(), userID, toBookOrders(orderRequest.Items))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	userID, err := h.service.GetUserIDByEmail(c.Request.Context(), email)
+	c.JSON(http.StatusCreated, newOrderView(order))
+}
+
+func (h handler) GetUserIDByEmail(c *gin.Context) {
+	var lookup struct {
+		Email string `form:"email" binding:"required,email"`
+	}
+	if !bindQuery(c, &lookup) {
+		return
+	}
+	userID, err := h.service.GetUserIDByEmail(c.Request.Context(), lookup.Email)
 	if err != nil {
-		log.Printf("Error getting user ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user ID"})
+		c.Error(err)
 		return
 	}
 
-	var books []BookOrder
-	for _, item := range orderRequest.Items {
-		books = append(books, BookOrder{
-			BookID:   item.BookID,
-			Quantity: item.Quantity,
-		})
+	if userID == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "No user found for this email"})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"userID": userID})
+}
 
-	if err := h.service.PlaceOrder(c.Request.Context(), userID, books); err != nil {
-		log.Printf("Error placing order: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h handler) GetBookByID(c *gin.Context) {
+	// /api/v1/books/:id takes the ID in the path, the legacy /book/ in the
+	// query string.
+	var lookup struct {
+		ID string `uri:"id" form:"id" binding:"required,id"`
+	}
+	bind := bindQuery
+	if c.Param("id") != "" {
+		bind = bindURI
+	}
+	if !bind(c, &lookup) {
 		return
 	}
-
-	c.JSON(http.StatusCreated, "order placed successfully")
+	book, err := h.service.GetBookByID(c.Request.Context(), lookup.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"book": newBookView(book)})
 }
 
-func (h handler) GetUserIDByEmail(c *gin.Context) {
-
-	email := c.Query("email")
-	if email == "" {
-		c.JSON(http.StatusBadRequest, "email parameter is required")
+func (h handler) CreateBook(c *gin.Context) {
+	var request BookRequest
+	if !bindJSON(c, &request) {
 		return
 	}
-	userID, err := h.service.GetUserIDByEmail(c.Request.Context(), email)
+	created, err := h.service.CreateBook(c.Request.Context(), request.toBook(""))
 	if err != nil {
-		log.Printf("Error getting user ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user ID"})
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusCreated, newBookView(created))
+}
 
-	if userID == "" {
-		c.JSON(http.StatusOK, gin.H{"message": "No user found for this email"})
+func (h handler) UpdateBook(c *gin.Context) {
+	var request BookRequest
+	if !bindJSON(c, &request) {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"userID": userID})
+	updated, err := h.service.UpdateBook(c.Request.Context(), request.toBook(c.Param("id")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newBookView(updated))
 }
 
-func (h handler) GetBookByID(c *gin.Context) {
-	id := c.Query("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+func (h handler) PatchBook(c *gin.Context) {
+	var request BookPatchRequest
+	if !bindJSON(c, &request) {
 		return
 	}
-	book, err := h.service.GetBookByID(c.Request.Context(), id)
+	updated, err := h.service.PatchBook(c.Request.Context(), c.Param("id"), request.toBookPatch())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get book"})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"book": book})
+	c.JSON(http.StatusOK, newBookView(updated))
+}
+
+func (h handler) DeleteBook(c *gin.Context) {
+	if err := h.service.DeleteBook(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetDatabaseStats reports the connection pool statistics of the
+// application's database.
+func (h handler) GetDatabaseStats(c *gin.Context) {
+	c.JSON(http.StatusOK, newDatabaseStatsView(h.app.DBStats()))
 }
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h handler) GetCart(c *gin.Context) {
+	cart, err := h.service.GetCart(c.Request.Context(), authenticatedUserID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCartView(cart))
+}
+
+func (h handler) ReplaceCart(c *gin.Context) {
+	var request CartRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	cart, err := h.service.ReplaceCart(c.Request.Context(), authenticatedUserID(c), toBookOrders(request.Items))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCartView(cart))
+}
+
+func (h handler) AddCartItem(c *gin.Context) {
+	var item OrderItemRequest
+	if !bindJSON(c, &item) {
+		return
+	}
+	cart, err := h.service.AddToCart(c.Request.Context(), authenticatedUserID(c), item.toBookOrder())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCartView(cart))
+}
+
+func (h handler) RemoveCartItem(c *gin.Context) {
+	cart, err := h.service.RemoveFromCart(c.Request.Context(), authenticatedUserID(c), c.Param("bookId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCartView(cart))
+}
+
+func (h handler) CheckoutCart(c *gin.Context) {
+	order, err := h.service.Checkout(c.Request.Context(), authenticatedUserID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newOrderView(order))
+}
@@ -0,0 +1,92 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckoutCart(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	tests := []struct {
+		name         string
+		order        api.Order
+		serviceError error
+		wantBody     string
+		wantCode     int
+	}{
+		{
+			name:     "order created",
+			order:    api.Order{ID: "9", UserID: "42", Status: api.OrderPending, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Items: []api.BookOrder{{BookID: "1", Quantity: 1, Title: "Dune", UnitPrice: 999, LineTotal: 999}}, Subtotal: 999, Total: 999},
+			wantBody: `{"id":"9","userId":"42","status":"pending","items":[{"bookId":"1","quantity":1,"title":"Dune","unitPrice":"9.99","lineTotal":"9.99"}],"subtotal":"9.99","tax":"0.00","total":"9.99","createdAt":"2024-05-01T12:00:00Z"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:         "prices changed",
+			serviceError: &api.PriceChangedError{BookIDs: []string{"1"}},
+			wantBody:     problem(http.StatusConflict, "price_changed", "cart prices changed, review the cart and retry", "/cart/checkout", gin.H{"bookIds": []string{"1"}}),
+			wantCode:     http.StatusConflict,
+		},
+		{
+			name:         "empty cart",
+			serviceError: api.ErrCartEmpty,
+			wantBody:     problem(http.StatusUnprocessableEntity, "cart_empty", "cart is empty", "/cart/checkout"),
+			wantCode:     http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "service error",
+			serviceError: errors.New("connection reset"),
+			wantBody:     problem(http.StatusInternalServerError, "internal", "internal server error", "/cart/checkout"),
+			wantCode:     http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", c, "token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil).Once()
+			mockService.On("Checkout", c, "42").Return(tt.order, tt.serviceError).Once()
+
+			r.POST("/cart/checkout", api.RequireAuth(mockService), api.NewHandler(app, mockService).CheckoutCart)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/cart/checkout", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func Test_GetCart(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	r := gin.Default()
+	r.Use(api.ErrorHandler())
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", c, "token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil).Once()
+	mockService.On("GetCart", c, "42").Return(api.Cart{
+		Items:    []api.CartItem{{BookID: "1", Title: "Dune", Quantity: 2, UnitPrice: 999, LineTotal: 1998, PriceChanged: true, InStock: true}},
+		Subtotal: 1998,
+	}, nil).Once()
+
+	r.GET("/cart", api.RequireAuth(mockService), api.NewHandler(app, mockService).GetCart)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/cart", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"items":[{"bookId":"1","title":"Dune","quantity":2,"unitPrice":"9.99","lineTotal":"19.98","priceChanged":true,"inStock":true}],"subtotal":"19.98"}`, w.Body.String())
+}
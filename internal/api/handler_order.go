@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h handler) CancelOrder(c *gin.Context) {
+	order, err := h.service.CancelOrder(c.Request.Context(), authenticatedUserID(c), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newOrderView(order))
+}
+
+func (h handler) TransitionOrder(c *gin.Context) {
+	var request StatusRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	order, err := h.service.TransitionOrder(c.Request.Context(), authenticatedUserID(c), c.Param("id"), request.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newOrderView(order))
+}
@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TransitionOrder(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	tests := []struct {
+		name         string
+		role         api.Role
+		serviceError error
+		wantBody     string
+		wantCode     int
+	}{
+		{
+			name:     "staff ships order",
+			role:     api.RoleStaff,
+			wantBody: `{"id":"9","userId":"42","status":"shipped","items":null,"subtotal":"0.00","tax":"0.00","total":"0.00","createdAt":"0001-01-01T00:00:00Z"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "customer is forbidden",
+			role:     api.RoleCustomer,
+			wantBody: problem(http.StatusForbidden, "insufficient_permissions", "insufficient permissions", "/orders/9/status"),
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid transition",
+			role:         api.RoleAdmin,
+			serviceError: &api.InvalidTransitionError{From: api.OrderPending, To: api.OrderShipped},
+			wantBody:     problem(http.StatusConflict, "invalid_transition", "cannot change order status from pending to shipped", "/orders/9/status"),
+			wantCode:     http.StatusConflict,
+		},
+		{
+			name:         "unknown order",
+			role:         api.RoleAdmin,
+			serviceError: api.ErrOrderNotFound,
+			wantBody:     problem(http.StatusNotFound, "order_not_found", "order not found", "/orders/9/status"),
+			wantCode:     http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", c, "token").Return(api.Principal{UserID: "1", Role: tt.role}, nil).Once()
+			mockService.On("TransitionOrder", c, "1", "9", api.OrderShipped).Return(api.Order{ID: "9", UserID: "42", Status: api.OrderShipped}, tt.serviceError).Once()
+
+			r.POST("/orders/:id/status", api.RequireAuth(mockService), api.RequirePermission(api.PermManageOrders), api.NewHandler(app, mockService).TransitionOrder)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/orders/9/status", strings.NewReader(`{"status":"shipped"}`))
+			req.Header.Set("Authorization", "Bearer token")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
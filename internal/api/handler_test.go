@@ -4,6 +4,7 @@ import (
 	"bookstore/internal/api"
 	"bookstore/internal/api/mocks"
 	"bookstore/internal/application"
+	"bookstore/internal/auth"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,8 +12,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -40,27 +43,47 @@ func Test_CreateAccount(t *testing.T) {
 			name:           "empty email case",
 			requestBodyStr: `{"email": "", "password": "password123"}`,
 			serviceError:   nil,
-			wantBody:       "{\"error\":\"email is required\"}",
-			wantCode:       http.StatusBadRequest,
+			wantBody:       invalid("/accounts", api.ValidationError{Field: "email", Message: "is required"}),
+			wantCode:       http.StatusUnprocessableEntity,
 		},
 		{
 			name:           "empty password case",
 			requestBodyStr: `{"email": "test@example.com", "password": ""}`,
 			serviceError:   nil,
-			wantBody:       "{\"error\":\"password is required\"}",
-			wantCode:       http.StatusBadRequest,
+			wantBody:       invalid("/accounts", api.ValidationError{Field: "password", Message: "is required"}),
+			wantCode:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "malformed email",
+			requestBodyStr: `{"email": "not-an-email", "password": "password123"}`,
+			wantBody:       invalid("/accounts", api.ValidationError{Field: "email", Message: "must be a valid email address"}),
+			wantCode:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "weak password",
+			requestBodyStr: `{"email": "test@example.com", "password": "password"}`,
+			wantBody:       invalid("/accounts", api.ValidationError{Field: "password", Message: "must be 8 to 72 bytes and contain a letter and a digit"}),
+			wantCode:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "email already registered",
+			requestBodyStr: `{"email": "test@example.com", "password": "password123"}`,
+			serviceError:   api.ErrEmailTaken,
+			wantBody:       problem(http.StatusConflict, "email_taken", "an account with this email already exists", "/accounts"),
+			wantCode:       http.StatusConflict,
 		},
 		{
 			name:           "service error case",
 			requestBodyStr: `{"email": "test@example.com", "password": "password123"}`,
-			serviceError:   errors.New("password is required"),
-			wantBody:       "{\"error\":\"password is required\"}",
+			serviceError:   errors.New("pq: connection refused"),
+			wantBody:       problem(http.StatusInternalServerError, "internal", "internal server error", "/accounts"),
 			wantCode:       http.StatusInternalServerError,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := gin.Default()
+			r.Use(api.ErrorHandler())
 			requestBody := strings.NewReader(tt.requestBodyStr)
 			mockService := new(mocks.Service)
 
@@ -72,6 +95,9 @@ func Test_CreateAccount(t *testing.T) {
 			r.ServeHTTP(w, req)
 			assert.Equal(t, tt.wantCode, w.Code)
 			assert.Equal(t, tt.wantBody, w.Body.String())
+			if tt.wantCode >= http.StatusBadRequest {
+				assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			}
 
 		})
 	}
@@ -80,46 +106,77 @@ func Test_CreateAccount(t *testing.T) {
 func Test_GetAllBooks(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
+	minPrice, inStock := api.Money(500), true
 	tests := []struct {
 		name         string
-		serviceBooks []api.Book
+		url          string
+		wantQuery    *api.BookQuery
+		servicePage  api.BookPage
 		serviceError error
 		wantBody     string
 		wantCode     int
 	}{
 		{
 			name:         "success case",
-			serviceBooks: []api.Book{{ID: "1", Title: "Book 1", Author: "Author 1", Description: "test", Price: 1234}},
+			url:          "/books",
+			wantQuery:    &api.BookQuery{Sort: api.SortByTitle},
+			servicePage:  api.BookPage{Items: []api.Book{{ID: "1", Title: "Book 1", Author: "Author 1", Description: "test", Price: 1234}}, NextCursor: "abc", Total: 3},
+			serviceError: nil,
+			wantBody:     `{"items":[{"id":"1","title":"Book 1","author":"Author 1","description":"test","price":"12.34","stock":0}],"next_cursor":"abc","total":3}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "filters and sort",
+			url:          "/books?sort=-price&author=Herbert&min_price=5&in_stock=true&limit=2&cursor=abc",
+			wantQuery:    &api.BookQuery{Sort: api.SortByPrice, Desc: true, Author: "Herbert", MinPrice: &minPrice, InStock: &inStock, Limit: 2, Cursor: "abc"},
+			servicePage:  api.BookPage{Items: []api.Book{}},
 			serviceError: nil,
-			wantBody:     `[{"id":"1","title":"Book 1","author":"Author 1","description":"test","price":1234}]`,
+			wantBody:     `{"items":[],"total":0}`,
 			wantCode:     http.StatusOK,
 		},
+		{
+			name:     "unknown sort",
+			url:      "/books?sort=isbn",
+			wantBody: invalid("/books", api.ValidationError{Field: "sort", Message: "must be one of title, author, price or created"}),
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "bad price",
+			url:      "/books?max_price=cheap",
+			wantBody: invalid("/books", api.ValidationError{Field: "max_price", Message: "must be an amount such as 12.34"}),
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "invalid cursor",
+			url:          "/books?cursor=nope",
+			wantQuery:    &api.BookQuery{Sort: api.SortByTitle, Cursor: "nope"},
+			serviceError: api.ValidationError{Field: "cursor", Message: "is invalid"},
+			wantBody:     invalid("/books", api.ValidationError{Field: "cursor", Message: "is invalid"}),
+			wantCode:     http.StatusUnprocessableEntity,
+		},
 		{
 			name:         "error case",
-			serviceBooks: nil,
+			url:          "/books",
+			wantQuery:    &api.BookQuery{Sort: api.SortByTitle},
 			serviceError: errors.New("failed to fetch books"),
-			wantBody:     `{"error":"failed to fetch the books"}`,
+			wantBody:     problem(http.StatusInternalServerError, "internal", "internal server error", "/books"),
 			wantCode:     http.StatusInternalServerError,
 		},
-		{
-			name:         "empty case",
-			serviceBooks: []api.Book{},
-			serviceError: nil,
-			wantBody:     `[]`,
-			wantCode:     http.StatusOK,
-		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			r := gin.Default()
+			r.Use(api.ErrorHandler())
 
-			mockService := new(mocks.Service)
+			mockService := mocks.NewService(t)
 
-			mockService.On("GetAllBooks", c).Return(tt.serviceBooks, tt.serviceError).Once()
+			if tt.wantQuery != nil {
+				mockService.On("GetAllBooks", c, *tt.wantQuery).Return(tt.servicePage, tt.serviceError).Once()
+			}
 			r.GET("/books", api.NewHandler(app, mockService).GetAllBooks)
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("GET", "/books", nil)
+			req, _ := http.NewRequest("GET", tt.url, nil)
 			r.ServeHTTP(w, req)
 			assert.Equal(t, tt.wantCode, w.Code)
 			assert.Equal(t, tt.wantBody, w.Body.String())
@@ -131,75 +188,72 @@ func Test_GetAllBooks(t *testing.T) {
 func Test_GetOrderHistory(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
+	placedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
 	tests := []struct {
 		name         string
-		email        string
+		authHeader   string
 		userID       string
+		authError    error
 		serviceError error
 		wantBody     string
 		wantCode     int
 	}{
 		{
 			name:         "success_case",
-			email:        "test@example.com",
+			authHeader:   "Bearer valid-token",
 			userID:       "user123",
 			serviceError: nil,
-			wantBody:     `[{"id":"123","userId":"user123","items":[{"bookId":"1","quantity":2,"title":""}]}]`,
+			wantBody:     `[{"id":"123","userId":"user123","status":"shipped","items":[{"bookId":"1","quantity":2,"title":"","unitPrice":"4.50","lineTotal":"9.00"}],"subtotal":"9.00","tax":"0.72","total":"9.72","createdAt":"2024-05-01T12:00:00Z","history":[{"to":"pending","changedBy":"user123","changedAt":"2024-05-01T12:00:00Z"},{"from":"pending","to":"paid","changedAt":"2024-05-01T12:05:00Z"},{"from":"paid","to":"shipped","changedBy":"7","changedAt":"2024-05-02T09:00:00Z"}]}]`,
 			wantCode:     http.StatusOK,
 		},
 		{
-			name:         "email_not_provided",
-			email:        "",
-			userID:       "",
-			serviceError: nil,
-			wantBody:     `{"error":"email parameter is required"}`,
-			wantCode:     http.StatusBadRequest,
+			name:       "missing_token",
+			authHeader: "",
+			wantBody:   problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/orders"),
+			wantCode:   http.StatusUnauthorized,
 		},
 		{
-			name:         "invalid_email",
-			email:        "invalid_email",
-			userID:       "",
-			serviceError: errors.New("error getting user ID"),
-			wantBody:     `{"error":"failed to get user ID"}`,
-			wantCode:     http.StatusInternalServerError,
+			name:       "invalid_token",
+			authHeader: "Bearer expired-token",
+			authError:  auth.ErrInvalidToken,
+			wantBody:   problem(http.StatusUnauthorized, "invalid_session", "invalid or expired session", "/orders"),
+			wantCode:   http.StatusUnauthorized,
 		},
 		{
 			name:         "error_fetching_order_history",
-			email:        "test@example.com",
-			userID:       "",
-			serviceError: errors.New("failed to get user ID"),
-			wantBody:     `{"error":"failed to get user ID"}`,
-			wantCode:     http.StatusInternalServerError,
-		},
-		{
-			name:         "error_fetching_user_id",
-			email:        "test@example.com",
-			userID:       "",
-			serviceError: errors.New("failed to get user ID"),
-			wantBody:     `{"error":"failed to get user ID"}`,
+			authHeader:   "Bearer valid-token",
+			userID:       "user123",
+			serviceError: errors.New("failed to fetch orders"),
+			wantBody:     problem(http.StatusInternalServerError, "internal", "internal server error", "/orders"),
 			wantCode:     http.StatusInternalServerError,
 		},
-		// {
-		// 	name:         "no_order_found",
-		// 	email:        "test@example.com",
-		// 	userID:       "user123",
-		// 	serviceError: nil,
-		// 	wantBody:     `{"message":"No Order found for this user"}`,
-		// 	wantCode:     http.StatusOK,
-		// },
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := gin.Default()
+			r.Use(api.ErrorHandler())
 			mockService := new(mocks.Service)
-			mockService.On("GetUserIDByEmail", c, tt.email).Return(tt.userID, tt.serviceError).Once()
+			token := strings.TrimPrefix(tt.authHeader, "Bearer ")
+			mockService.On("Authenticate", c, token).Return(api.Principal{UserID: tt.userID, Role: api.RoleCustomer}, tt.authError).Once()
 			mockService.On("GetOrderHistory", c, tt.userID).Return([]api.Order{
-				{ID: "123", UserID: tt.userID, Items: []api.BookOrder{{BookID: "1", Quantity: 2, Title: ""}}},
-			}, nil).Once()
-			//mockService.On("GetOrderHistory", c, tt.userID).Return([]api.Order{}, nil).Once()
-			r.GET("/orders", api.NewHandler(app, mockService).GetOrderHistory)
+				{
+					ID: "123", UserID: tt.userID, Status: api.OrderShipped,
+					Items:    []api.BookOrder{{BookID: "1", Quantity: 2, Title: "", UnitPrice: 450, LineTotal: 900}},
+					Subtotal: 900, Tax: 72, Total: 972,
+					CreatedAt: placedAt,
+					History: []api.OrderStatusChange{
+						{To: api.OrderPending, ChangedBy: tt.userID, ChangedAt: placedAt},
+						{From: api.OrderPending, To: api.OrderPaid, ChangedAt: placedAt.Add(5 * time.Minute)},
+						{From: api.OrderPaid, To: api.OrderShipped, ChangedBy: "7", ChangedAt: placedAt.Add(21 * time.Hour)},
+					},
+				},
+			}, tt.serviceError).Once()
+			r.GET("/orders", api.RequireAuth(mockService), api.NewHandler(app, mockService).GetOrderHistory)
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("GET", "/orders?email="+tt.email, nil)
+			req, _ := http.NewRequest("GET", "/orders", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
 			r.ServeHTTP(w, req)
 			assert.Equal(t, tt.wantCode, w.Code)
 			assert.Equal(t, tt.wantBody, w.Body.String())
@@ -210,62 +264,157 @@ func Test_GetOrderHistory(t *testing.T) {
 func Test_PlaceOrder(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
+	tooMany := make([]api.OrderItemRequest, 51)
+	for i := range tooMany {
+		tooMany[i] = api.OrderItemRequest{BookID: strconv.Itoa(i + 1), Quantity: 1}
+	}
 	tests := []struct {
 		name         string
 		requestBody  interface{}
-		email        string
+		authHeader   string
 		userID       string
+		authError    error
 		serviceError error
 		wantBody     string
 		wantCode     int
 	}{
 		{
 			name: "success_case",
-			requestBody: api.Order{
-				Items: []api.BookOrder{
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
 					{BookID: "1", Quantity: 2},
 					{BookID: "2", Quantity: 1},
 				},
 			},
-			email:        "test@example.com",
+			authHeader:   "Bearer valid-token",
 			userID:       "user123",
 			serviceError: nil,
-			wantBody:     "order placed successfully",
+			wantBody:     `{"id":"9","userId":"user123","status":"pending","items":[{"bookId":"1","quantity":2,"title":"Dune","unitPrice":"9.99","lineTotal":"19.98"}],"subtotal":"19.98","tax":"0.00","total":"19.98","createdAt":"2024-05-01T12:00:00Z"}`,
+			wantCode:     http.StatusCreated,
+		},
+		{
+			name:         "client_supplied_ids_are_ignored",
+			requestBody:  json.RawMessage(`{"id":"77","userId":"999","items":[{"bookId":"1","quantity":2}]}`),
+			authHeader:   "Bearer valid-token",
+			userID:       "user123",
+			serviceError: nil,
+			wantBody:     `{"id":"9","userId":"user123","status":"pending","items":[{"bookId":"1","quantity":2,"title":"Dune","unitPrice":"9.99","lineTotal":"19.98"}],"subtotal":"19.98","tax":"0.00","total":"19.98","createdAt":"2024-05-01T12:00:00Z"}`,
 			wantCode:     http.StatusCreated,
 		},
 		{
 			name:         "invalid_request_body",
 			requestBody:  "invalid",
-			email:        "test@example.com",
-			userID:       "",
+			authHeader:   "Bearer valid-token",
+			userID:       "user123",
 			serviceError: nil,
-			wantBody:     `{"error":"invalid request body"}`,
+			wantBody:     problem(http.StatusBadRequest, "invalid_body", "invalid request body", "/orders"),
 			wantCode:     http.StatusBadRequest,
 		},
 		{
-			name: "error_getting_user_id",
-			requestBody: api.Order{
-				Items: []api.BookOrder{
+			name: "missing_token",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
 					{BookID: "1", Quantity: 2},
 				},
 			},
-			email:        "test@example.com",
-			userID:       "",
-			serviceError: errors.New("failed to get user ID"),
-			wantBody:     `{"error":"failed to get user ID"}`,
-			wantCode:     http.StatusInternalServerError,
+			authHeader: "",
+			wantBody:   problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/orders"),
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name: "invalid_token",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
+					{BookID: "1", Quantity: 2},
+				},
+			},
+			authHeader: "Bearer forged-token",
+			authError:  auth.ErrInvalidToken,
+			wantBody:   problem(http.StatusUnauthorized, "invalid_session", "invalid or expired session", "/orders"),
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name: "insufficient_stock",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
+					{BookID: "1", Quantity: 2},
+					{BookID: "2", Quantity: 5},
+				},
+			},
+			authHeader:   "Bearer valid-token",
+			userID:       "user123",
+			serviceError: &api.InsufficientStockError{BookIDs: []string{"1", "2"}},
+			wantBody:     problem(http.StatusConflict, "insufficient_stock", "insufficient stock", "/orders", gin.H{"bookIds": []string{"1", "2"}}),
+			wantCode:     http.StatusConflict,
+		},
+		{
+			name: "invalid_quantity",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
+					{BookID: "1", Quantity: -1},
+				},
+			},
+			authHeader: "Bearer valid-token",
+			userID:     "user123",
+			wantBody:   invalid("/orders", api.ValidationError{Field: "items[0].quantity", Message: "must be at least 1"}),
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "no_items",
+			requestBody: api.OrderRequest{Items: []api.OrderItemRequest{}},
+			authHeader:  "Bearer valid-token",
+			userID:      "user123",
+			wantBody:    invalid("/orders", api.ValidationError{Field: "items", Message: "must have at least 1 items"}),
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "too_many_items",
+			requestBody: api.OrderRequest{Items: tooMany},
+			authHeader:  "Bearer valid-token",
+			userID:      "user123",
+			wantBody:    invalid("/orders", api.ValidationError{Field: "items", Message: "must have at most 50 items"}),
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name: "duplicate_books",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
+					{BookID: "1", Quantity: 2},
+					{BookID: "1", Quantity: 1},
+				},
+			},
+			authHeader: "Bearer valid-token",
+			userID:     "user123",
+			wantBody:   invalid("/orders", api.ValidationError{Field: "items", Message: "must not contain the same book twice"}),
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name: "every_invalid_field_is_reported",
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
+					{BookID: "abc", Quantity: 2},
+					{BookID: "2", Quantity: 101},
+				},
+			},
+			authHeader: "Bearer valid-token",
+			userID:     "user123",
+			wantBody: invalid("/orders",
+				api.ValidationError{Field: "items[0].bookId", Message: "must be a positive integer"},
+				api.ValidationError{Field: "items[1].quantity", Message: "must be at most 100"},
+			),
+			wantCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "error_placing_order",
-			requestBody: api.Order{
-				Items: []api.BookOrder{
+			requestBody: api.OrderRequest{
+				Items: []api.OrderItemRequest{
 					{BookID: "1", Quantity: 2},
 				},
 			},
-			email:        "test@example.com",
+			authHeader:   "Bearer valid-token",
 			userID:       "user123",
-			serviceError: errors.New("failed to get user ID"),
-			wantBody:     `{"error":"failed to get user ID"}`,
+			serviceError: errors.New("failed to insert order"),
+			wantBody:     problem(http.StatusInternalServerError, "internal", "internal server error", "/orders"),
 			wantCode:     http.StatusInternalServerError,
 		},
 	}
@@ -273,15 +422,26 @@ func Test_PlaceOrder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := gin.Default()
+			r.Use(api.ErrorHandler())
 			mockService := new(mocks.Service)
-			mockService.On("GetUserIDByEmail", c, tt.email).Return(tt.userID, tt.serviceError).Once()
-			mockService.On("PlaceOrder", c, tt.userID, mock.AnythingOfType("[]api.BookOrder")).Return(tt.serviceError).Once()
-			r.POST("/orders", api.NewHandler(app, mockService).PlaceOrder)
+			token := strings.TrimPrefix(tt.authHeader, "Bearer ")
+			mockService.On("Authenticate", c, token).Return(api.Principal{UserID: tt.userID, Role: api.RoleCustomer}, tt.authError).Once()
+			placed := api.Order{
+				ID: "9", UserID: tt.userID, Status: api.OrderPending,
+				CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Items:     []api.BookOrder{{BookID: "1", Quantity: 2, Title: "Dune", UnitPrice: 999, LineTotal: 1998}},
+				Subtotal:  1998, Total: 1998,
+			}
+			mockService.On("PlaceOrder", c, tt.userID, mock.AnythingOfType("[]api.BookOrder")).Return(placed, tt.serviceError).Once()
+			r.POST("/orders", api.RequireAuth(mockService), api.NewHandler(app, mockService).PlaceOrder)
 			w := httptest.NewRecorder()
 
 			reqBody, _ := json.Marshal(tt.requestBody)
-			req, _ := http.NewRequest("POST", "/orders?email="+tt.email, bytes.NewBuffer(reqBody))
+			req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBody))
 			req.Header.Set("Content-Type", "application/json")
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
 			r.ServeHTTP(w, req)
 
 			fmt.Println("Actual response body:", w.Body.String())
@@ -293,6 +453,63 @@ func Test_PlaceOrder(t *testing.T) {
 	}
 }
 
+func Test_CreateSession(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	expiresAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		requestBodyStr string
+		session        api.Session
+		serviceError   error
+		wantBody       string
+		wantCode       int
+	}{
+		{
+			name:           "happy case",
+			requestBodyStr: `{"email": "test@example.com", "password": "password123"}`,
+			session:        api.Session{Token: "signed-token", UserID: "1", ExpiresAt: expiresAt},
+			wantBody:       `{"token":"signed-token","userId":"1","expiresAt":"2024-05-02T12:00:00Z"}`,
+			wantCode:       http.StatusCreated,
+		},
+		{
+			name:           "missing password",
+			requestBodyStr: `{"email": "test@example.com"}`,
+			wantBody:       invalid("/sessions", api.ValidationError{Field: "password", Message: "is required"}),
+			wantCode:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "wrong credentials",
+			requestBodyStr: `{"email": "test@example.com", "password": "wrong"}`,
+			serviceError:   api.ErrInvalidCredentials,
+			wantBody:       problem(http.StatusUnauthorized, "invalid_credentials", "invalid email or password", "/sessions"),
+			wantCode:       http.StatusUnauthorized,
+		},
+		{
+			name:           "service error",
+			requestBodyStr: `{"email": "test@example.com", "password": "password123"}`,
+			serviceError:   errors.New("connection refused"),
+			wantBody:       problem(http.StatusInternalServerError, "internal", "internal server error", "/sessions"),
+			wantCode:       http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := new(mocks.Service)
+			mockService.On("CreateSession", c, "test@example.com", mock.AnythingOfType("string")).Return(tt.session, tt.serviceError).Once()
+
+			r.POST("/sessions", api.NewHandler(app, mockService).CreateSession)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/sessions", strings.NewReader(tt.requestBodyStr))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
 func Test_GetBookByID(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
@@ -307,20 +524,21 @@ func Test_GetBookByID(t *testing.T) {
 			name:         "id_not_provided",
 			id:           "",
 			serviceError: nil,
-			wantBody:     `{"error":"id is required"}`,
-			wantCode:     http.StatusBadRequest,
+			wantBody:     invalid("/book_detail", api.ValidationError{Field: "id", Message: "is required"}),
+			wantCode:     http.StatusUnprocessableEntity,
 		},
 		{
 			name:         "success_case",
 			id:           "123",
 			serviceError: nil,
-			wantBody:     "{\"book\":{\"id\":\"\",\"title\":\"\",\"author\":\"\",\"description\":\"\",\"price\":0}}",
+			wantBody:     "{\"book\":{\"id\":\"\",\"title\":\"\",\"author\":\"\",\"description\":\"\",\"price\":\"0.00\",\"stock\":0}}",
 			wantCode:     http.StatusOK,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := gin.Default()
+			r.Use(api.ErrorHandler())
 			mockService := new(mocks.Service)
 			mockService.On("GetBookByID", c, tt.id).Return(api.Book{}, tt.serviceError)
 
@@ -334,3 +552,194 @@ func Test_GetBookByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreateBook(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	tests := []struct {
+		name           string
+		role           api.Role
+		requestBodyStr string
+		serviceBook    api.Book
+		serviceError   error
+		wantBody       string
+		wantCode       int
+	}{
+		{
+			name:           "admin creates book",
+			role:           api.RoleAdmin,
+			requestBodyStr: `{"title":"Dune","author":"Frank Herbert","description":"","price":"9.99"}`,
+			serviceBook:    api.Book{ID: "7", Title: "Dune", Author: "Frank Herbert", Price: 999, Stock: 3},
+			wantBody:       `{"id":"7","title":"Dune","author":"Frank Herbert","description":"","price":"9.99","stock":3}`,
+			wantCode:       http.StatusCreated,
+		},
+		{
+			name:           "customer is forbidden",
+			role:           api.RoleCustomer,
+			requestBodyStr: `{"title":"Dune","author":"Frank Herbert","price":9.99}`,
+			wantBody:       problem(http.StatusForbidden, "insufficient_permissions", "insufficient permissions", "/books"),
+			wantCode:       http.StatusForbidden,
+		},
+		{
+			name:           "staff is forbidden",
+			role:           api.RoleStaff,
+			requestBodyStr: `{"title":"Dune","author":"Frank Herbert","price":9.99}`,
+			wantBody:       problem(http.StatusForbidden, "insufficient_permissions", "insufficient permissions", "/books"),
+			wantCode:       http.StatusForbidden,
+		},
+		{
+			name:           "validation error",
+			role:           api.RoleAdmin,
+			requestBodyStr: `{"title":"","author":"Frank Herbert","price":9.99}`,
+			serviceError:   api.ValidationError{Field: "title", Message: "is required"},
+			wantBody:       invalid("/books", api.ValidationError{Field: "title", Message: "is required"}),
+			wantCode:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid body",
+			role:           api.RoleAdmin,
+			requestBodyStr: `{"price":"9.999"}`,
+			wantBody:       problem(http.StatusBadRequest, "invalid_body", "invalid request body", "/books"),
+			wantCode:       http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", c, "token").Return(api.Principal{UserID: "1", Role: tt.role}, nil).Once()
+			mockService.On("CreateBook", c, mock.AnythingOfType("api.Book")).Return(tt.serviceBook, tt.serviceError).Once()
+
+			r.POST("/books", api.RequireAuth(mockService), api.RequirePermission(api.PermManageCatalog), api.NewHandler(app, mockService).CreateBook)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/books", strings.NewReader(tt.requestBodyStr))
+			req.Header.Set("Authorization", "Bearer token")
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func Test_DeleteBook(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	tests := []struct {
+		name         string
+		serviceError error
+		wantBody     string
+		wantCode     int
+	}{
+		{
+			name:     "deleted",
+			wantBody: "",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:         "not found",
+			serviceError: api.ErrBookNotFound,
+			wantBody:     problem(http.StatusNotFound, "book_not_found", "book not found", "/books/7"),
+			wantCode:     http.StatusNotFound,
+		},
+		{
+			name:         "referenced by orders",
+			serviceError: api.ErrBookInUse,
+			wantBody:     problem(http.StatusConflict, "book_in_use", "book is referenced by existing orders", "/books/7"),
+			wantCode:     http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := new(mocks.Service)
+			mockService.On("DeleteBook", c, "7").Return(tt.serviceError).Once()
+
+			r.DELETE("/books/:id", api.NewHandler(app, mockService).DeleteBook)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/books/7", nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func Test_SearchBooks(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	tests := []struct {
+		name           string
+		url            string
+		wantSearch     *api.BookSearch
+		serviceResults []api.BookSearchResult
+		serviceError   error
+		wantBody       string
+		wantCode       int
+	}{
+		{
+			name:           "success case",
+			url:            "/books/search?q=dun&limit=5",
+			wantSearch:     &api.BookSearch{Query: "dun", Limit: 5},
+			serviceResults: []api.BookSearchResult{{Book: api.Book{ID: "1", Title: "Dune", Author: "Frank Herbert", Price: 999}, Rank: 0.5, Headline: "<b>Dune</b>"}},
+			wantBody:       `{"items":[{"book":{"id":"1","title":"Dune","author":"Frank Herbert","description":"","price":"9.99","stock":0},"rank":0.5,"headline":"\u003cb\u003eDune\u003c/b\u003e"}]}`,
+			wantCode:       http.StatusOK,
+		},
+		{
+			name:         "missing query",
+			url:          "/books/search",
+			wantSearch:   &api.BookSearch{},
+			serviceError: api.ValidationError{Field: "q", Message: "is required"},
+			wantBody:     invalid("/books/search", api.ValidationError{Field: "q", Message: "is required"}),
+			wantCode:     http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "bad limit",
+			url:      "/books/search?q=dune&limit=x",
+			wantBody: invalid("/books/search", api.ValidationError{Field: "limit", Message: "must be between 1 and 100"}),
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "error case",
+			url:          "/books/search?q=dune",
+			wantSearch:   &api.BookSearch{Query: "dune"},
+			serviceError: errors.New("db down"),
+			wantBody:     problem(http.StatusInternalServerError, "internal", "internal server error", "/books/search"),
+			wantCode:     http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.Use(api.ErrorHandler())
+			mockService := mocks.NewService(t)
+			if tt.wantSearch != nil {
+				mockService.On("SearchBooks", c, *tt.wantSearch).Return(tt.serviceResults, tt.serviceError).Once()
+			}
+			r.GET("/books/search", api.NewHandler(app, mockService).SearchBooks)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+// problem is the problem+json body api.ErrorHandler writes for an error.
+func problem(status int, code, detail, instance string, details ...gin.H) string {
+	body := gin.H{"type": "about:blank", "title": http.StatusText(status), "status": status, "code": code, "detail": detail, "instance": instance}
+	for _, d := range details {
+		for k, v := range d {
+			body[k] = v
+		}
+	}
+	data, _ := json.Marshal(body)
+	return string(data)
+}
+
+// invalid is the 422 problem body for a request with rejected fields.
+func invalid(instance string, errs ...api.ValidationError) string {
+	return problem(http.StatusUnprocessableEntity, "validation_failed", api.ValidationErrors(errs).Error(), instance, gin.H{"errors": errs})
+}
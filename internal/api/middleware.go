@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bookstore/internal/auth"
+	"bookstore/internal/logging"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer" session
+// token and stores the caller's Principal on the context for the handler.
+// Like the handlers, it reports failures through ErrorHandler.
+func RequireAuth(service Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.Error(ErrAuthRequired)
+			c.Abort()
+			return
+		}
+		principal, err := service.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidToken) {
+				err = ErrInvalidSession.Wrap(err)
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		logging.SetUserID(c.Request.Context(), principal.UserID)
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequirePermission consults the role policy for the caller authenticated by
+// RequireAuth, which must run first.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principalFrom(c)
+		if !ok {
+			c.Error(ErrAuthRequired)
+			c.Abort()
+			return
+		}
+		if !Allowed(principal.Role, perm) {
+			c.Error(ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func principalFrom(c *gin.Context) (Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := v.(Principal)
+	return principal, ok
+}
+
+// authenticatedUserID returns the user ID stored by RequireAuth.
+func authenticatedUserID(c *gin.Context) string {
+	principal, _ := principalFrom(c)
+	return principal.UserID
+}
@@ -14,6 +14,52 @@ type Repository struct {
 	mock.Mock
 }
 
+// AddCartItem provides a mock function with given fields: ctx, userID, bookID, quantity
+func (_m *Repository) AddCartItem(ctx context.Context, userID string, bookID string, quantity int) error {
+	ret := _m.Called(ctx, userID, bookID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddCartItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, userID, bookID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckoutCart provides a mock function with given fields: ctx, userID
+func (_m *Repository) CheckoutCart(ctx context.Context, userID string) (api.Order, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckoutCart")
+	}
+
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Order, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Order); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateAccount provides a mock function with given fields: ctx, email, password
 func (_m *Repository) CreateAccount(ctx context.Context, email string, password string) error {
 	ret := _m.Called(ctx, email, password)
@@ -32,29 +78,73 @@ func (_m *Repository) CreateAccount(ctx context.Context, email string, password
 	return r0
 }
 
-// GetAllBooks provides a mock function with given fields: ctx
-func (_m *Repository) GetAllBooks(ctx context.Context) ([]api.Book, error) {
-	ret := _m.Called(ctx)
+// CreateBook provides a mock function with given fields: ctx, book
+func (_m *Repository) CreateBook(ctx context.Context, book api.Book) (api.Book, error) {
+	ret := _m.Called(ctx, book)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) (api.Book, error)); ok {
+		return rf(ctx, book)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) api.Book); ok {
+		r0 = rf(ctx, book)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.Book) error); ok {
+		r1 = rf(ctx, book)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteBook provides a mock function with given fields: ctx, bookID
+func (_m *Repository) DeleteBook(ctx context.Context, bookID string) error {
+	ret := _m.Called(ctx, bookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, bookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllBooks provides a mock function with given fields: ctx, query
+func (_m *Repository) GetAllBooks(ctx context.Context, query api.BookQuery) (api.BookPage, error) {
+	ret := _m.Called(ctx, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAllBooks")
 	}
 
-	var r0 []api.Book
+	var r0 api.BookPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]api.Book, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookQuery) (api.BookPage, error)); ok {
+		return rf(ctx, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []api.Book); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookQuery) api.BookPage); ok {
+		r0 = rf(ctx, query)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]api.Book)
-		}
+		r0 = ret.Get(0).(api.BookPage)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, api.BookQuery) error); ok {
+		r1 = rf(ctx, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,6 +180,92 @@ func (_m *Repository) GetBookByID(ctx context.Context, bookID string) (api.Book,
 	return r0, r1
 }
 
+// GetBooksByIDs provides a mock function with given fields: ctx, bookIDs
+func (_m *Repository) GetBooksByIDs(ctx context.Context, bookIDs []string) ([]api.Book, error) {
+	ret := _m.Called(ctx, bookIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBooksByIDs")
+	}
+
+	var r0 []api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]api.Book, error)); ok {
+		return rf(ctx, bookIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []api.Book); ok {
+		r0 = rf(ctx, bookIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.Book)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, bookIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCart provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetCart(ctx context.Context, userID string) (api.Cart, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 api.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Cart, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Cart); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: ctx, orderID
+func (_m *Repository) GetOrder(ctx context.Context, orderID string) (api.Order, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrder")
+	}
+
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Order, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Order); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrderHistory provides a mock function with given fields: ctx, email
 func (_m *Repository) GetOrderHistory(ctx context.Context, email string) ([]api.Order, error) {
 	ret := _m.Called(ctx, email)
@@ -120,6 +296,62 @@ func (_m *Repository) GetOrderHistory(ctx context.Context, email string) ([]api.
 	return r0, r1
 }
 
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Repository) GetUserByEmail(ctx context.Context, email string) (api.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 api.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(api.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetUserByID(ctx context.Context, userID string) (api.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 api.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserIDByEmail provides a mock function with given fields: ctx, email
 func (_m *Repository) GetUserIDByEmail(ctx context.Context, email string) (string, error) {
 	ret := _m.Called(ctx, email)
@@ -148,17 +380,221 @@ func (_m *Repository) GetUserIDByEmail(ctx context.Context, email string) (strin
 	return r0, r1
 }
 
+// PatchBook provides a mock function with given fields: ctx, bookID, patch
+func (_m *Repository) PatchBook(ctx context.Context, bookID string, patch api.BookPatch) (api.Book, error) {
+	ret := _m.Called(ctx, bookID, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookPatch) (api.Book, error)); ok {
+		return rf(ctx, bookID, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookPatch) api.Book); ok {
+		r0 = rf(ctx, bookID, patch)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.BookPatch) error); ok {
+		r1 = rf(ctx, bookID, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PlaceOrder provides a mock function with given fields: ctx, email, books
-func (_m *Repository) PlaceOrder(ctx context.Context, email string, books []api.BookOrder) error {
+func (_m *Repository) PlaceOrder(ctx context.Context, email string, books []api.BookOrder) (api.Order, error) {
 	ret := _m.Called(ctx, email, books)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PlaceOrder")
 	}
 
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) (api.Order, error)); ok {
+		return rf(ctx, email, books)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) api.Order); ok {
+		r0 = rf(ctx, email, books)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []api.BookOrder) error); ok {
+		r1 = rf(ctx, email, books)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefreshCartPrices provides a mock function with given fields: ctx, userID
+func (_m *Repository) RefreshCartPrices(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshCartPrices")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveCartItems provides a mock function with given fields: ctx, userID, bookIDs
+func (_m *Repository) RemoveCartItems(ctx context.Context, userID string, bookIDs []string) error {
+	ret := _m.Called(ctx, userID, bookIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCartItems")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, userID, bookIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceCart provides a mock function with given fields: ctx, userID, items
+func (_m *Repository) ReplaceCart(ctx context.Context, userID string, items []api.BookOrder) error {
+	ret := _m.Called(ctx, userID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceCart")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) error); ok {
-		r0 = rf(ctx, email, books)
+		r0 = rf(ctx, userID, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchBooks provides a mock function with given fields: ctx, search
+func (_m *Repository) SearchBooks(ctx context.Context, search api.BookSearch) ([]api.BookSearchResult, error) {
+	ret := _m.Called(ctx, search)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchBooks")
+	}
+
+	var r0 []api.BookSearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookSearch) ([]api.BookSearchResult, error)); ok {
+		return rf(ctx, search)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookSearch) []api.BookSearchResult); ok {
+		r0 = rf(ctx, search)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.BookSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.BookSearch) error); ok {
+		r1 = rf(ctx, search)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *Repository) SetUserRole(ctx context.Context, userID string, role api.Role) error {
+	ret := _m.Called(ctx, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.Role) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBook provides a mock function with given fields: ctx, book
+func (_m *Repository) UpdateBook(ctx context.Context, book api.Book) (api.Book, error) {
+	ret := _m.Called(ctx, book)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) (api.Book, error)); ok {
+		return rf(ctx, book)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) api.Book); ok {
+		r0 = rf(ctx, book)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.Book) error); ok {
+		r1 = rf(ctx, book)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOrderStatus provides a mock function with given fields: ctx, update
+func (_m *Repository) UpdateOrderStatus(ctx context.Context, update api.StatusUpdate) error {
+	ret := _m.Called(ctx, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOrderStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.StatusUpdate) error); ok {
+		r0 = rf(ctx, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, userID, passwordHash
+func (_m *Repository) UpdatePassword(ctx context.Context, userID string, passwordHash string) error {
+	ret := _m.Called(ctx, userID, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, passwordHash)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -14,6 +14,118 @@ type Service struct {
 	mock.Mock
 }
 
+// AddToCart provides a mock function with given fields: ctx, userID, item
+func (_m *Service) AddToCart(ctx context.Context, userID string, item api.BookOrder) (api.Cart, error) {
+	ret := _m.Called(ctx, userID, item)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToCart")
+	}
+
+	var r0 api.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookOrder) (api.Cart, error)); ok {
+		return rf(ctx, userID, item)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookOrder) api.Cart); ok {
+		r0 = rf(ctx, userID, item)
+	} else {
+		r0 = ret.Get(0).(api.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.BookOrder) error); ok {
+		r1 = rf(ctx, userID, item)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Authenticate provides a mock function with given fields: ctx, token
+func (_m *Service) Authenticate(ctx context.Context, token string) (api.Principal, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 api.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Principal, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Principal); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(api.Principal)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrder provides a mock function with given fields: ctx, userID, orderID
+func (_m *Service) CancelOrder(ctx context.Context, userID string, orderID string) (api.Order, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelOrder")
+	}
+
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.Order, error)); ok {
+		return rf(ctx, userID, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.Order); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Checkout provides a mock function with given fields: ctx, userID
+func (_m *Service) Checkout(ctx context.Context, userID string) (api.Order, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checkout")
+	}
+
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Order, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Order); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateAccount provides a mock function with given fields: ctx, email, password
 func (_m *Service) CreateAccount(ctx context.Context, email string, password string) error {
 	ret := _m.Called(ctx, email, password)
@@ -32,29 +144,101 @@ func (_m *Service) CreateAccount(ctx context.Context, email string, password str
 	return r0
 }
 
-// GetAllBooks provides a mock function with given fields: ctx
-func (_m *Service) GetAllBooks(ctx context.Context) ([]api.Book, error) {
-	ret := _m.Called(ctx)
+// CreateBook provides a mock function with given fields: ctx, book
+func (_m *Service) CreateBook(ctx context.Context, book api.Book) (api.Book, error) {
+	ret := _m.Called(ctx, book)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) (api.Book, error)); ok {
+		return rf(ctx, book)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) api.Book); ok {
+		r0 = rf(ctx, book)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.Book) error); ok {
+		r1 = rf(ctx, book)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSession provides a mock function with given fields: ctx, email, password
+func (_m *Service) CreateSession(ctx context.Context, email string, password string) (api.Session, error) {
+	ret := _m.Called(ctx, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSession")
+	}
+
+	var r0 api.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.Session, error)); ok {
+		return rf(ctx, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.Session); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Get(0).(api.Session)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteBook provides a mock function with given fields: ctx, bookID
+func (_m *Service) DeleteBook(ctx context.Context, bookID string) error {
+	ret := _m.Called(ctx, bookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, bookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllBooks provides a mock function with given fields: ctx, query
+func (_m *Service) GetAllBooks(ctx context.Context, query api.BookQuery) (api.BookPage, error) {
+	ret := _m.Called(ctx, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAllBooks")
 	}
 
-	var r0 []api.Book
+	var r0 api.BookPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]api.Book, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookQuery) (api.BookPage, error)); ok {
+		return rf(ctx, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []api.Book); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookQuery) api.BookPage); ok {
+		r0 = rf(ctx, query)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]api.Book)
-		}
+		r0 = ret.Get(0).(api.BookPage)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, api.BookQuery) error); ok {
+		r1 = rf(ctx, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,6 +274,64 @@ func (_m *Service) GetBookByID(ctx context.Context, bookID string) (api.Book, er
 	return r0, r1
 }
 
+// GetBooksByIDs provides a mock function with given fields: ctx, bookIDs
+func (_m *Service) GetBooksByIDs(ctx context.Context, bookIDs []string) ([]api.Book, error) {
+	ret := _m.Called(ctx, bookIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBooksByIDs")
+	}
+
+	var r0 []api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]api.Book, error)); ok {
+		return rf(ctx, bookIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []api.Book); ok {
+		r0 = rf(ctx, bookIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.Book)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, bookIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCart provides a mock function with given fields: ctx, userID
+func (_m *Service) GetCart(ctx context.Context, userID string) (api.Cart, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 api.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.Cart, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.Cart); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrderHistory provides a mock function with given fields: ctx, email
 func (_m *Service) GetOrderHistory(ctx context.Context, email string) ([]api.Order, error) {
 	ret := _m.Called(ctx, email)
@@ -120,6 +362,34 @@ func (_m *Service) GetOrderHistory(ctx context.Context, email string) ([]api.Ord
 	return r0, r1
 }
 
+// GetUser provides a mock function with given fields: ctx, userID
+func (_m *Service) GetUser(ctx context.Context, userID string) (api.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 api.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(api.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserIDByEmail provides a mock function with given fields: ctx, email
 func (_m *Service) GetUserIDByEmail(ctx context.Context, email string) (string, error) {
 	ret := _m.Called(ctx, email)
@@ -148,22 +418,230 @@ func (_m *Service) GetUserIDByEmail(ctx context.Context, email string) (string,
 	return r0, r1
 }
 
+// PatchBook provides a mock function with given fields: ctx, bookID, patch
+func (_m *Service) PatchBook(ctx context.Context, bookID string, patch api.BookPatch) (api.Book, error) {
+	ret := _m.Called(ctx, bookID, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookPatch) (api.Book, error)); ok {
+		return rf(ctx, bookID, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.BookPatch) api.Book); ok {
+		r0 = rf(ctx, bookID, patch)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.BookPatch) error); ok {
+		r1 = rf(ctx, bookID, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PlaceOrder provides a mock function with given fields: ctx, email, books
-func (_m *Service) PlaceOrder(ctx context.Context, email string, books []api.BookOrder) error {
+func (_m *Service) PlaceOrder(ctx context.Context, email string, books []api.BookOrder) (api.Order, error) {
 	ret := _m.Called(ctx, email, books)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PlaceOrder")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) error); ok {
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) (api.Order, error)); ok {
+		return rf(ctx, email, books)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) api.Order); ok {
 		r0 = rf(ctx, email, books)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(api.Order)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string, []api.BookOrder) error); ok {
+		r1 = rf(ctx, email, books)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFromCart provides a mock function with given fields: ctx, userID, bookID
+func (_m *Service) RemoveFromCart(ctx context.Context, userID string, bookID string) (api.Cart, error) {
+	ret := _m.Called(ctx, userID, bookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFromCart")
+	}
+
+	var r0 api.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.Cart, error)); ok {
+		return rf(ctx, userID, bookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.Cart); ok {
+		r0 = rf(ctx, userID, bookID)
+	} else {
+		r0 = ret.Get(0).(api.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, bookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceCart provides a mock function with given fields: ctx, userID, items
+func (_m *Service) ReplaceCart(ctx context.Context, userID string, items []api.BookOrder) (api.Cart, error) {
+	ret := _m.Called(ctx, userID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceCart")
+	}
+
+	var r0 api.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) (api.Cart, error)); ok {
+		return rf(ctx, userID, items)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []api.BookOrder) api.Cart); ok {
+		r0 = rf(ctx, userID, items)
+	} else {
+		r0 = ret.Get(0).(api.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []api.BookOrder) error); ok {
+		r1 = rf(ctx, userID, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchBooks provides a mock function with given fields: ctx, search
+func (_m *Service) SearchBooks(ctx context.Context, search api.BookSearch) ([]api.BookSearchResult, error) {
+	ret := _m.Called(ctx, search)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchBooks")
+	}
+
+	var r0 []api.BookSearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookSearch) ([]api.BookSearchResult, error)); ok {
+		return rf(ctx, search)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.BookSearch) []api.BookSearchResult); ok {
+		r0 = rf(ctx, search)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.BookSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.BookSearch) error); ok {
+		r1 = rf(ctx, search)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionOrder provides a mock function with given fields: ctx, actorID, orderID, status
+func (_m *Service) TransitionOrder(ctx context.Context, actorID string, orderID string, status api.OrderStatus) (api.Order, error) {
+	ret := _m.Called(ctx, actorID, orderID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionOrder")
+	}
+
+	var r0 api.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.OrderStatus) (api.Order, error)); ok {
+		return rf(ctx, actorID, orderID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.OrderStatus) api.Order); ok {
+		r0 = rf(ctx, actorID, orderID, status)
+	} else {
+		r0 = ret.Get(0).(api.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, api.OrderStatus) error); ok {
+		r1 = rf(ctx, actorID, orderID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateBook provides a mock function with given fields: ctx, book
+func (_m *Service) UpdateBook(ctx context.Context, book api.Book) (api.Book, error) {
+	ret := _m.Called(ctx, book)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBook")
+	}
+
+	var r0 api.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) (api.Book, error)); ok {
+		return rf(ctx, book)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, api.Book) api.Book); ok {
+		r0 = rf(ctx, book)
+	} else {
+		r0 = ret.Get(0).(api.Book)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, api.Book) error); ok {
+		r1 = rf(ctx, book)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyCredentials provides a mock function with given fields: ctx, email, password
+func (_m *Service) VerifyCredentials(ctx context.Context, email string, password string) (api.User, error) {
+	ret := _m.Called(ctx, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyCredentials")
+	}
+
+	var r0 api.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.User, error)); ok {
+		return rf(ctx, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.User); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Get(0).(api.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
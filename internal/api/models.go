@@ -1,37 +1,95 @@
 package api
 
+import "time"
+
+// The types in this file are the domain model shared by the Service and
+// Repository interfaces. They carry no JSON or database tags: requests are
+// decoded into the DTOs in requests.go, responses are rendered from the views
+// in views.go and the repository scans into the rows in rows.go.
+
+// Order is a placed order. Amounts are the prices captured when the order
+// was placed, not the current catalog prices.
 type Order struct {
-	ID     string      `json:"id"`
-	UserID string      `json:"userId"`
-	Items  []BookOrder `json:"items"`
+	ID        string
+	UserID    string
+	Status    OrderStatus
+	Items     []BookOrder
+	Subtotal  Money
+	Tax       Money
+	Total     Money
+	CreatedAt time.Time
+	History   []OrderStatusChange
 }
 
-type OrderItem struct {
-	BookID   string `json:"bookId"`
-	Quantity int    `json:"quantity"`
+// User is an account. PasswordHash holds the bcrypt hash, or a legacy
+// plaintext password that is rehashed on the next login.
+type User struct {
+	ID           string
+	Email        string
+	PasswordHash string
+	Role         Role
 }
 
-type User struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+// Principal is the authenticated caller resolved from a session token.
+type Principal struct {
+	UserID string
+	Role   Role
+}
+
+// Session is a signed token for UserID. Token is sent back as
+// "Authorization: Bearer <token>" on user-scoped routes.
+type Session struct {
+	Token     string
+	UserID    string
+	ExpiresAt time.Time
 }
 
 type Book struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Author      string  `json:"author"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string
+	Title       string
+	Author      string
+	Description string
+	Price       Money
+	Stock       int
+	CreatedAt   time.Time
+}
+
+// BookPatch carries the fields of a partial book update; nil fields are left
+// unchanged.
+type BookPatch struct {
+	Title       *string
+	Author      *string
+	Description *string
+	Price       *Money
+	Stock       *int
 }
 
+// BookOrder is a line of an order or cart request: a quantity of a book.
+// Title, UnitPrice and LineTotal are filled in once the book is priced.
 type BookOrder struct {
-	BookID   string `json:"bookId"`
-	Quantity int    `json:"quantity"`
-	Title    string `json:"title"`
+	BookID    string
+	Quantity  int
+	Title     string
+	UnitPrice Money
+	LineTotal Money
+}
+
+// Cart is a user's persistent shopping cart priced at current catalog
+// prices.
+type Cart struct {
+	Items    []CartItem
+	Subtotal Money
 }
 
-type BookOrdersReq struct {
-	BookOrder
-	Book
+// CartItem is a line in a cart. PriceChanged is set when the catalog price
+// differs from the price the user last saw; checkout is refused until the
+// cart has been reviewed.
+type CartItem struct {
+	BookID       string
+	Title        string
+	Quantity     int
+	UnitPrice    Money
+	LineTotal    Money
+	PriceChanged bool
+	InStock      bool
 }
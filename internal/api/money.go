@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units (cents). It is encoded in JSON
+// as a decimal string such as "12.34" so that clients never round-trip prices
+// through floating point. Decoding also accepts a bare JSON number.
+type Money int64
+
+const minorUnitsPerMajor = 100
+
+// ParseMoney parses a decimal amount with at most two fractional digits.
+func ParseMoney(s string) (Money, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasFrac && (!isDigits(frac) || len(frac) > 2)) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is out of range", s)
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorUnitsPerMajor, v%minorUnitsPerMajor)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(bytes.TrimSpace(data))
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Times returns the amount multiplied by a quantity.
+func (m Money) Times(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// BasisPoints returns bps/10000 of the amount, rounded half away from zero.
+// It is used for tax, where 825 basis points is 8.25%.
+func (m Money) BasisPoints(bps int) Money {
+	product := int64(m) * int64(bps)
+	if product < 0 {
+		return Money(-((-product + 5000) / 10000))
+	}
+	return Money((product + 5000) / 10000)
+}
@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    api.Money
+		wantErr bool
+	}{
+		{input: "12.34", want: 1234},
+		{input: "12.3", want: 1230},
+		{input: "12", want: 1200},
+		{input: "0.07", want: 7},
+		{input: "-1.50", want: -150},
+		{input: "1.234", wantErr: true},
+		{input: "1.", wantErr: true},
+		{input: ".5", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "+1", wantErr: true},
+		{input: "--1", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := api.ParseMoney(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_JSON(t *testing.T) {
+	out, err := json.Marshal(api.Money(1205))
+	assert.NoError(t, err)
+	assert.Equal(t, `"12.05"`, string(out))
+
+	var m api.Money
+	assert.NoError(t, json.Unmarshal([]byte(`"19.99"`), &m))
+	assert.Equal(t, api.Money(1999), m)
+	assert.NoError(t, json.Unmarshal([]byte(`19.9`), &m))
+	assert.Equal(t, api.Money(1990), m)
+	assert.Error(t, json.Unmarshal([]byte(`19.999`), &m))
+}
+
+func Test_Money_BasisPoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount api.Money
+		bps    int
+		want   api.Money
+	}{
+		{name: "no tax", amount: 1999, bps: 0, want: 0},
+		{name: "exact", amount: 10000, bps: 825, want: 825},
+		{name: "rounds half up", amount: 1000, bps: 825, want: 83},
+		{name: "rounds down", amount: 1234, bps: 700, want: 86},
+		{name: "negative rounds away from zero", amount: -1000, bps: 825, want: -83},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.amount.BasisPoints(tt.bps))
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_OpenAPI_Routes fails when a /api/v1 route is added to or removed from
+// the router without updating openapi/openapi.yaml.
+func Test_OpenAPI_Routes(t *testing.T) {
+	doc, err := api.OpenAPI()
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	var routed []string
+	for _, route := range api.NewRouter(application.NewAppMock(), new(mocks.Service)).Routes() {
+		path, ok := strings.CutPrefix(route.Path, api.APIPrefix)
+		if !ok {
+			continue
+		}
+		for _, segment := range strings.Split(path, "/") {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				path = strings.Replace(path, segment, "{"+name+"}", 1)
+			}
+		}
+		routed = append(routed, route.Method+" "+path)
+	}
+	sort.Strings(documented)
+	sort.Strings(routed)
+	assert.Equal(t, routed, documented)
+}
+
+// Test_OpenAPI_Schemas fails when a request or view type gains, loses or
+// renames a JSON field, or a required field, that its schema does not
+// describe, or when a field's schema type, format or enum does not match
+// what the field encodes to.
+func Test_OpenAPI_Schemas(t *testing.T) {
+	doc, err := api.OpenAPI()
+	require.NoError(t, err)
+
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{"AccountRequest", api.AccountRequest{}},
+		{"LoginRequest", api.LoginRequest{}},
+		{"OrderItemRequest", api.OrderItemRequest{}},
+		{"OrderRequest", api.OrderRequest{}},
+		{"CartRequest", api.CartRequest{}},
+		{"BookRequest", api.BookRequest{}},
+		{"BookPatchRequest", api.BookPatchRequest{}},
+		{"StatusRequest", api.StatusRequest{}},
+		{"Book", api.BookView{}},
+		{"BookPage", api.BookPageView{}},
+		{"BookSearchResult", api.BookSearchResultView{}},
+		{"BookSearch", api.BookSearchView{}},
+		{"OrderItem", api.OrderItemView{}},
+		{"OrderStatusChange", api.OrderStatusChangeView{}},
+		{"Order", api.OrderView{}},
+		{"CartItem", api.CartItemView{}},
+		{"Cart", api.CartView{}},
+		{"Session", api.SessionView{}},
+		{"DatabaseStats", api.DatabaseStatsView{}},
+		{"FieldError", api.ValidationError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			ref, ok := doc.Components.Schemas[tt.schema]
+			require.True(t, ok, "schema %s is missing", tt.schema)
+			schema := ref.Value
+
+			var fields, required []string
+			typ := reflect.TypeOf(tt.value)
+			isRequest := strings.HasSuffix(typ.Name(), "Request")
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+				fields = append(fields, name)
+				rules := strings.Split(f.Tag.Get("binding"), ",")
+				if property, ok := schema.Properties[name]; ok {
+					want := fieldSchema(f.Type, isRequest)
+					if contains(rules, "email") {
+						want.format = "email"
+					}
+					assert.Equal(t, want, documentedSchema(property.Value), "schema of %s", name)
+				}
+				// Views always send fields that are not omitempty; requests
+				// must send those that are required or have a lower bound.
+				if (!isRequest && !strings.Contains(opts, "omitempty")) ||
+					(isRequest && (contains(rules, "required") || contains(rules, "min=1"))) {
+					required = append(required, name)
+				}
+			}
+			var properties []string
+			for name := range schema.Properties {
+				properties = append(properties, name)
+			}
+			sort.Strings(fields)
+			sort.Strings(properties)
+			sort.Strings(required)
+			documentedRequired := append([]string(nil), schema.Required...)
+			sort.Strings(documentedRequired)
+
+			assert.Equal(t, fields, properties, "properties")
+			assert.Equal(t, required, documentedRequired, "required")
+		})
+	}
+}
+
+// schemaShape is the part of a schema Test_OpenAPI_Schemas compares. Types
+// of anyOf alternatives are joined with "|".
+type schemaShape struct {
+	typ    string
+	format string
+	enum   []any
+}
+
+var (
+	moneyType       = reflect.TypeOf(api.Money(0))
+	orderStatusType = reflect.TypeOf(api.OrderStatus(""))
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// fieldSchema is the schema a field of type t should be documented with.
+// Money is rendered as a string, and requests also accept it as a number.
+func fieldSchema(t reflect.Type, isRequest bool) schemaShape {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == moneyType && isRequest:
+		return schemaShape{typ: "string|number"}
+	case t == moneyType:
+		return schemaShape{typ: "string"}
+	case t == orderStatusType:
+		return schemaShape{typ: "string", enum: []any{
+			string(api.OrderPending), string(api.OrderPaid), string(api.OrderShipped),
+			string(api.OrderDelivered), string(api.OrderCancelled), string(api.OrderRefunded),
+		}}
+	case t == timeType:
+		return schemaShape{typ: "string", format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return schemaShape{typ: "string"}
+	case reflect.Bool:
+		return schemaShape{typ: "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return schemaShape{typ: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schemaShape{typ: "number"}
+	case reflect.Slice:
+		return schemaShape{typ: "array"}
+	case reflect.Struct:
+		return schemaShape{typ: "object"}
+	}
+	return schemaShape{typ: t.String()}
+}
+
+func documentedSchema(s *openapi3.Schema) schemaShape {
+	shape := schemaShape{format: s.Format, enum: s.Enum}
+	if s.Type != nil {
+		shape.typ = strings.Join(s.Type.Slice(), "|")
+	}
+	for _, alternative := range s.AnyOf {
+		if shape.typ != "" {
+			shape.typ += "|"
+		}
+		shape.typ += strings.Join(alternative.Value.Type.Slice(), "|")
+	}
+	return shape
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_OpenAPI_Served(t *testing.T) {
+	r := api.NewRouter(application.NewAppMock(), new(mocks.Service))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var spec struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.1.0", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/books/{id}")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/docs", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	page := w.Body.String()
+	assert.Contains(t, page, `url: "/openapi.json"`)
+
+	// Every script and stylesheet the page loads is embedded and served
+	// here, so the page works offline and no CDN can change it.
+	assets := regexp.MustCompile(`(?:src|href)="([^"]+)"`).FindAllStringSubmatch(page, -1)
+	require.NotEmpty(t, assets)
+	for _, asset := range assets {
+		require.True(t, strings.HasPrefix(asset[1], "/docs/"), "%s is not served by the API", asset[1])
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, asset[1], nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, asset[1])
+		assert.Greater(t, w.Body.Len(), 100000, "%s is the full bundle", asset[1])
+	}
+}
+
+func Test_ValidateRequests(t *testing.T) {
+	doc, err := api.OpenAPI()
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "valid_order",
+			method:   http.MethodPost,
+			path:     "/api/v1/orders",
+			body:     `{"items":[{"bookId":"1","quantity":2}]}`,
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+		{
+			name:     "invalid_order_fields",
+			method:   http.MethodPost,
+			path:     "/api/v1/orders",
+			body:     `{"items":[{"bookId":"abc","quantity":0}]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: invalid("/api/v1/orders",
+				api.ValidationError{Field: "items[0].bookId", Message: `string doesn't match the regular expression "^[1-9][0-9]*$"`},
+				api.ValidationError{Field: "items[0].quantity", Message: "number must be at least 1"},
+			),
+		},
+		{
+			name:     "body_is_not_json",
+			method:   http.MethodPost,
+			path:     "/api/v1/orders",
+			body:     `{"items":`,
+			wantCode: http.StatusBadRequest,
+			wantBody: problem(http.StatusBadRequest, "invalid_body", "invalid request body", "/api/v1/orders"),
+		},
+		{
+			name:     "invalid_query_parameter",
+			method:   http.MethodGet,
+			path:     "/api/v1/books?limit=500&in_stock=maybe",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: invalid("/api/v1/books",
+				api.ValidationError{Field: "in_stock", Message: `value maybe: an invalid boolean: invalid syntax`},
+				api.ValidationError{Field: "limit", Message: "number must be at most 100"},
+			),
+		},
+		{
+			name:     "missing_query_parameter",
+			method:   http.MethodGet,
+			path:     "/api/v1/books/search",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: invalid("/api/v1/books/search", api.ValidationError{Field: "q", Message: "value is required but missing"}),
+		},
+		{
+			name:     "invalid_path_parameter",
+			method:   http.MethodGet,
+			path:     "/api/v1/books/abc",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: invalid("/api/v1/books/abc",
+				api.ValidationError{Field: "id", Message: `string doesn't match the regular expression "^[1-9][0-9]*$"`},
+			),
+		},
+		{
+			name:     "undocumented_route_passes",
+			method:   http.MethodGet,
+			path:     "/api/v1/internal",
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(api.ErrorHandler())
+			v1 := r.Group(api.APIPrefix, api.ValidateRequests(doc))
+			ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+			v1.POST("/orders", ok)
+			v1.GET("/books", ok)
+			v1.GET("/books/search", ok)
+			v1.GET("/books/:id", ok)
+			v1.GET("/internal", ok)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
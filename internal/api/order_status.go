@@ -0,0 +1,73 @@
+package api
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses each status may move to. Cancelled and
+// refunded are terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderCancelled, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+	OrderCancelled: nil,
+	OrderRefunded:  nil,
+}
+
+// customerCancellable are the statuses from which customers may cancel their
+// own orders; once shipped only staff can act on an order.
+var customerCancellable = []OrderStatus{OrderPending, OrderPaid}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// Restocks reports whether moving an order from -> to returns its copies to
+// stock: cancellations and refunds do, unless the books already left the
+// warehouse, in which case they only come back through a return.
+func Restocks(from, to OrderStatus) bool {
+	if to != OrderCancelled && to != OrderRefunded {
+		return false
+	}
+	return from != OrderShipped && from != OrderDelivered
+}
+
+// CanTransition reports whether the transition table allows from -> to.
+func CanTransition(from, to OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusChange is an entry in an order's status history. From is empty
+// for the initial status.
+type OrderStatusChange struct {
+	From      OrderStatus
+	To        OrderStatus
+	ChangedBy string
+	ChangedAt time.Time
+}
+
+// StatusUpdate is a validated transition handed to the repository. Restock
+// returns the ordered copies to stock in the same transaction.
+type StatusUpdate struct {
+	OrderID string
+	From    OrderStatus
+	To      OrderStatus
+	ActorID string
+	Restock bool
+}
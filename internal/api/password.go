@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bookstore/internal/apperror"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned when an email/password pair does not
+// match a stored account. It deliberately does not say which half was wrong.
+var ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+
+const (
+	minPasswordBytes = 8
+	// maxPasswordBytes is the longest input bcrypt will hash; longer
+	// passwords are rejected rather than silently truncated.
+	maxPasswordBytes = 72
+)
+
+// PasswordAllowed is the policy for new passwords: 8 to 72 bytes with at
+// least one letter and one digit.
+func PasswordAllowed(password string) bool {
+	if len(password) < minPasswordBytes || len(password) > maxPasswordBytes {
+		return false
+	}
+	return strings.IndexFunc(password, unicode.IsLetter) >= 0 && strings.IndexFunc(password, unicode.IsDigit) >= 0
+}
+
+func hashPassword(password string, cost int) (string, error) {
+	if len(password) > maxPasswordBytes {
+		return "", ValidationError{"password", fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches the stored value. Accounts
+// created before hashing was introduced hold the raw password; those are
+// compared in constant time so they can be upgraded on login.
+func checkPassword(stored, password string) bool {
+	if !isBcryptHash(stored) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+// checkNoPassword compares password with a hash at cost that no account
+// has. Logins for unknown emails call it so that they take as long as a
+// wrong password and do not reveal which accounts exist.
+func checkNoPassword(password string, cost int) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash(cost), []byte(password))
+}
+
+// dummyHashes caches the hash checkNoPassword uses per cost.
+var dummyHashes sync.Map
+
+func dummyHash(cost int) []byte {
+	if hash, ok := dummyHashes.Load(cost); ok {
+		return hash.([]byte)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("not the password of any account"), cost)
+	if err != nil {
+		// Only an invalid cost fails, and hashPassword reports that.
+		return nil
+	}
+	actual, _ := dummyHashes.LoadOrStore(cost, hash)
+	return actual.([]byte)
+}
+
+// needsRehash reports whether stored should be replaced by a fresh hash at
+// the configured cost.
+func needsRehash(stored string, cost int) bool {
+	if !isBcryptHash(stored) {
+		return true
+	}
+	current, err := bcrypt.Cost([]byte(stored))
+	return err != nil || current != cost
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
@@ -0,0 +1,38 @@
+package api
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// Permission names an action that is restricted to some roles.
+type Permission string
+
+const (
+	PermManageCatalog Permission = "catalog:manage"
+	PermManageOrders  Permission = "orders:manage"
+	// PermViewSystem grants access to operational data such as database
+	// pool statistics.
+	PermViewSystem Permission = "system:view"
+)
+
+// rolePermissions is the access policy: the permissions granted to each
+// role. Customers only get what every authenticated user can do.
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: nil,
+	RoleStaff:    {PermManageOrders},
+	RoleAdmin:    {PermManageCatalog, PermManageOrders, PermViewSystem},
+}
+
+// Allowed reports whether role has been granted perm.
+func Allowed(role Role, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
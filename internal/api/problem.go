@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bookstore/internal/apperror"
+	"bookstore/internal/logging"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+var problemStatus = map[apperror.Kind]int{
+	apperror.KindBadRequest:   http.StatusBadRequest,
+	apperror.KindValidation:   http.StatusUnprocessableEntity,
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+// ErrorHandler renders the last error a handler attached with c.Error as an
+// RFC 7807 problem+json body, with the request ID when there is one. Errors
+// that are not classified by apperror are logged and reported as a generic
+// internal error, so database and other internal messages never reach
+// clients.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+func writeProblem(c *gin.Context, err error) {
+	appErr := apperror.From(err)
+	status, ok := problemStatus[appErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	ctx := c.Request.Context()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(ctx).ErrorContext(ctx, "Error handling request",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+	}
+
+	body := make(gin.H, len(appErr.Details)+7)
+	for k, v := range appErr.Details {
+		body[k] = v
+	}
+	body["type"] = "about:blank"
+	body["title"] = http.StatusText(status)
+	body["status"] = status
+	body["detail"] = appErr.Message
+	body["instance"] = c.Request.URL.Path
+	body["code"] = appErr.Code
+	if id := logging.RequestID(ctx); id != "" {
+		body["requestId"] = id
+	}
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, body)
+}
+
+// recovery turns a panic into a logged internal error, reported like any
+// other so that the response carries the request ID.
+func recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).ErrorContext(ctx, "Panic handling request", "panic", recovered, "stack", string(debug.Stack()))
+		writeProblem(c, apperror.Internal(fmt.Errorf("panic: %v", recovered)))
+	})
+}
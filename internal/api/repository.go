@@ -2,38 +2,72 @@ package api
 
 import (
 	"bookstore/internal/application"
-	"bookstore/internal/application/config"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresDB struct {
 	db *sql.DB
 }
 
-func NewPostgresDB(cfg *config.Config) (*PostgresDB, error) {
-	//db, err := gorm.Open(postgres.Open(cfg.DBConnectionString()))
-	db, err := sql.Open("postgres", cfg.DBConnectionString())
-	if err != nil {
-		return nil, err
+// NewPostgresDB wraps the pool owned by the application.
+func NewPostgresDB(db *sql.DB) *PostgresDB {
+	return &PostgresDB{db: db}
+}
+
+// Postgres error codes that are reported to clients as conflicts.
+const (
+	foreignKeyViolation pq.ErrorCode = "23503"
+	uniqueViolation     pq.ErrorCode = "23505"
+)
+
+// pqErrorCode returns the SQLSTATE of a Postgres error, or "" for other
+// errors.
+func pqErrorCode(err error) pq.ErrorCode {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code
 	}
-	return &PostgresDB{
-		db: db,
-	}, nil
+	return ""
+}
 
+// DB exposes the underlying pool for callers such as the schema migrator.
+func (p *PostgresDB) DB() *sql.DB {
+	return p.db
 }
 
 type Repository interface {
-	GetAllBooks(ctx context.Context) ([]Book, error)
-	PlaceOrder(ctx context.Context, email string, books []BookOrder) error
+	GetAllBooks(ctx context.Context, query BookQuery) (BookPage, error)
+	SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error)
+	PlaceOrder(ctx context.Context, email string, books []BookOrder) (Order, error)
+	CheckoutCart(ctx context.Context, userID string) (Order, error)
 	CreateAccount(ctx context.Context, email, password string) error
 	GetOrderHistory(ctx context.Context, email string) ([]Order, error)
 	GetUserIDByEmail(ctx context.Context, email string) (string, error)
 	GetBookByID(ctx context.Context, bookID string) (Book, error)
+	GetBooksByIDs(ctx context.Context, bookIDs []string) ([]Book, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	UpdatePassword(ctx context.Context, userID, passwordHash string) error
+	SetUserRole(ctx context.Context, userID string, role Role) error
+	GetUserByID(ctx context.Context, userID string) (User, error)
+	CreateBook(ctx context.Context, book Book) (Book, error)
+	UpdateBook(ctx context.Context, book Book) (Book, error)
+	PatchBook(ctx context.Context, bookID string, patch BookPatch) (Book, error)
+	DeleteBook(ctx context.Context, bookID string) error
+	GetCart(ctx context.Context, userID string) (Cart, error)
+	AddCartItem(ctx context.Context, userID, bookID string, quantity int) error
+	ReplaceCart(ctx context.Context, userID string, items []BookOrder) error
+	RemoveCartItems(ctx context.Context, userID string, bookIDs []string) error
+	RefreshCartPrices(ctx context.Context, userID string) error
+	GetOrder(ctx context.Context, orderID string) (Order, error)
+	UpdateOrderStatus(ctx context.Context, update StatusUpdate) error
 }
 
 type repository struct {
@@ -48,144 +82,455 @@ func NewRepository(app *application.Application, dbRepo PostgresDB) Repository {
 	}
 }
 
-func (r *repository) CreateAccount(ctx context.Context, email, password string) error {
+// CreateAccount stores a new user. passwordHash must already be hashed; the
+// repository never sees plaintext passwords.
+func (r *repository) CreateAccount(ctx context.Context, email, passwordHash string) error {
 	query := "INSERT INTO users (email, password) VALUES ($1, $2)"
-	// err := utitlity.ExecuteInsertQuery(ctx, r.db.db, query, email, password)
-	// //return fmt.Errorf("failed to create account: %v", err)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to create account: %v", err)
-	// }
-	// return nil
-	_, err := r.db.db.Exec(query, email, password)
+	_, err := r.db.db.ExecContext(ctx, query, email, passwordHash)
 	if err != nil {
+		if pqErrorCode(err) == uniqueViolation {
+			return ErrEmailTaken.Wrap(err)
+		}
 		return fmt.Errorf("failed to create account: %v", err)
 	}
 	return nil
 }
 
-func (r *repository) GetAllBooks(ctx context.Context) ([]Book, error) {
-	query := "SELECT id, title, author, description, price FROM books"
-	rows, err := r.db.db.QueryContext(ctx, query)
+// GetUserByEmail returns the user including its stored password hash. A
+// zero User is returned when no account matches.
+func (r *repository) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	query := "SELECT id, email, password, role FROM users WHERE email = $1"
+	var row userRow
+	err := r.db.db.QueryRowContext(ctx, query, email).Scan(&row.ID, &row.Email, &row.Password, &row.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return User{}, nil
+		}
+		return User{}, fmt.Errorf("failed to get user: %v", err)
+	}
+	return row.toUser(), nil
+}
+
+// GetUserByID returns a zero User when no account matches.
+func (r *repository) GetUserByID(ctx context.Context, userID string) (User, error) {
+	query := "SELECT id, email, role FROM users WHERE id = $1"
+	var row userRow
+	err := r.db.db.QueryRowContext(ctx, query, userID).Scan(&row.ID, &row.Email, &row.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return User{}, nil
+		}
+		return User{}, fmt.Errorf("failed to get user: %v", err)
+	}
+	return row.toUser(), nil
+}
+
+func (r *repository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	query := "UPDATE users SET password = $1 WHERE id = $2"
+	_, err := r.db.db.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	return nil
+}
+
+func (r *repository) SetUserRole(ctx context.Context, userID string, role Role) error {
+	query := "UPDATE users SET role = $1 WHERE id = $2"
+	res, err := r.db.db.ExecContext(ctx, query, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// bookSortColumns maps each BookSort to its column and the SQL type its
+// cursor value is cast to.
+var bookSortColumns = map[BookSort][2]string{
+	SortByTitle:   {"title", "text"},
+	SortByAuthor:  {"author", "text"},
+	SortByPrice:   {"price_cents", "bigint"},
+	SortByCreated: {"created_at", "timestamptz"},
+}
+
+// GetAllBooks returns one page of the catalog using keyset pagination on
+// (sort column, id). query must have been normalized.
+func (r *repository) GetAllBooks(ctx context.Context, query BookQuery) (BookPage, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if query.Author != "" {
+		where = append(where, "lower(author) = lower("+arg(query.Author)+")")
+	}
+	if query.MinPrice != nil {
+		where = append(where, "price_cents >= "+arg(*query.MinPrice))
+	}
+	if query.MaxPrice != nil {
+		where = append(where, "price_cents <= "+arg(*query.MaxPrice))
+	}
+	if query.InStock != nil {
+		if *query.InStock {
+			where = append(where, "stock > 0")
+		} else {
+			where = append(where, "stock = 0")
+		}
+	}
+
+	page := BookPage{Items: make([]Book, 0)}
+	filter := ""
+	if len(where) > 0 {
+		filter = " WHERE " + strings.Join(where, " AND ")
+	}
+	if err := r.db.db.QueryRowContext(ctx, "SELECT count(*) FROM books"+filter, args...).Scan(&page.Total); err != nil {
+		return BookPage{}, fmt.Errorf("failed to count books: %v", err)
+	}
+
+	column, castTo := bookSortColumns[query.Sort][0], bookSortColumns[query.Sort][1]
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if query.After != nil {
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s)", column, compare, arg(query.After.Value), castTo, arg(query.After.ID)))
+	}
+	filter = ""
+	if len(where) > 0 {
+		filter = " WHERE " + strings.Join(where, " AND ")
+	}
+	// One extra row tells us whether there is another page.
+	limit := max(query.Limit, 0)
+	sqlQuery := fmt.Sprintf(`
+        SELECT %s
+        FROM books%s
+        ORDER BY %s %s, id %s
+        LIMIT %s`, bookColumns(""), filter, column, direction, direction, arg(limit+1))
+	rows, err := r.db.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return BookPage{}, fmt.Errorf("failed to get books: %v", err)
 	}
 	defer rows.Close()
 
-	var books []Book
+	var last BookCursor
 	for rows.Next() {
-		var book Book
-		err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Description, &book.Price)
-		if err != nil {
-			log.Println("Error scanning book row:", err)
-			continue
+		var row bookRow
+		if err := rows.Scan(row.dest()...); err != nil {
+			return BookPage{}, err
 		}
-		books = append(books, book)
+		if len(page.Items) == limit {
+			if limit > 0 {
+				page.NextCursor = last.Encode()
+			}
+			break
+		}
+		book := row.toBook()
+		page.Items = append(page.Items, book)
+		last = bookCursorAt(query, book)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows:%v", err)
+		return BookPage{}, fmt.Errorf("error iterating over rows:%v", err)
 	}
-	return books, nil
+	return page, nil
+}
 
+// bookCursorAt returns the cursor that resumes a listing after book.
+func bookCursorAt(query BookQuery, book Book) BookCursor {
+	c := BookCursor{Sort: query.Sort, Desc: query.Desc}
+	c.ID, _ = strconv.ParseInt(book.ID, 10, 64)
+	switch query.Sort {
+	case SortByAuthor:
+		c.Value = book.Author
+	case SortByPrice:
+		c.Value = strconv.FormatInt(int64(book.Price), 10)
+	case SortByCreated:
+		c.Value = book.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		c.Value = book.Title
+	}
+	return c
 }
+
 func (r *repository) GetUserIDByEmail(ctx context.Context, email string) (string, error) {
 	var userID string
 	query := "SELECT id FROM users WHERE email = $1"
 	err := r.db.db.QueryRowContext(ctx, query, email).Scan(&userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("email not found:%v", err)
+			return "", ErrUserNotFound
 		}
 		return "", fmt.Errorf("failed to get user ID: %v", err)
 	}
 	return userID, nil
 }
 
-func (r *repository) PlaceOrder(ctx context.Context, userID string, books []BookOrder) error {
-
+// PlaceOrder records the order and takes the ordered copies out of stock in a
+// single transaction. If any book is short the whole order is rejected with an
+// *InsufficientStockError.
+func (r *repository) PlaceOrder(ctx context.Context, userID string, books []BookOrder) (Order, error) {
 	tx, err := r.db.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %v", err)
+		return Order{}, fmt.Errorf("failed to start transaction: %v", err)
 	}
 	defer tx.Rollback()
 
-	var orderID int
-	query := "INSERT INTO orders (user_id) VALUES ($1) RETURNING id"
-	err = tx.QueryRowContext(ctx, query, userID).Scan(&orderID)
+	order, err := r.placeOrder(ctx, tx, userID, mergeBookOrders(books), false)
 	if err != nil {
-		return fmt.Errorf("failed to insert order: %v", err)
+		return Order{}, err
 	}
 
-	for _, book := range books {
-		query = "INSERT INTO order_items (order_id, book_id, quantity) VALUES ($1, $2, $3)"
-		_, err = tx.ExecContext(ctx, query, orderID, book.BookID, book.Quantity)
-		if err != nil {
-			return fmt.Errorf("failed to insert order item: %v", err)
+	err = tx.Commit()
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return order, nil
+}
+
+// placeOrder inserts the order for books, which must not repeat a book, and
+// takes the copies out of stock within tx. The book rows are locked in ID
+// order so concurrent orders for overlapping books queue up instead of
+// deadlocking, and the unit prices read under that lock are stored on the
+// order items. With checkPrices set, the UnitPrice of every line is the
+// price the buyer agreed to and the order is refused with a
+// *PriceChangedError if the locked price differs.
+func (r *repository) placeOrder(ctx context.Context, tx *sql.Tx, userID string, books []BookOrder, checkPrices bool) (Order, error) {
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.BookID
+	}
+	query := "SELECT " + bookColumns("") + " FROM books WHERE id = ANY($1::bigint[]) ORDER BY id FOR UPDATE"
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to lock books: %v", err)
+	}
+	catalog := make(map[string]Book, len(books))
+	for rows.Next() {
+		var row bookRow
+		if err := rows.Scan(row.dest()...); err != nil {
+			rows.Close()
+			return Order{}, err
 		}
+		book := row.toBook()
+		catalog[book.ID] = book
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Order{}, fmt.Errorf("error iterating over rows:%v", err)
 	}
 
-	err = tx.Commit()
+	order, err := priceOrder(userID, books, catalog, checkPrices, r.taxRateBPS())
 	if err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return Order{}, err
 	}
 
-	return nil
+	query = `
+        INSERT INTO orders (user_id, status, subtotal_cents, tax_cents, total_cents)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+	err = tx.QueryRowContext(ctx, query, userID, order.Status, order.Subtotal, order.Tax, order.Total).Scan(&order.ID, &order.CreatedAt)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to insert order: %v", err)
+	}
+	query = "INSERT INTO order_status_history (order_id, to_status, changed_by, changed_at) VALUES ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctx, query, order.ID, order.Status, userID, order.CreatedAt)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to record order status: %v", err)
+	}
+	order.History = []OrderStatusChange{{To: order.Status, ChangedBy: userID, ChangedAt: order.CreatedAt}}
+
+	for _, item := range order.Items {
+		query = "UPDATE books SET stock = stock - $1 WHERE id = $2"
+		_, err = tx.ExecContext(ctx, query, item.Quantity, item.BookID)
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to update stock: %v", err)
+		}
+		query = "INSERT INTO order_items (order_id, book_id, quantity, unit_price_cents) VALUES ($1, $2, $3, $4)"
+		_, err = tx.ExecContext(ctx, query, order.ID, item.BookID, item.Quantity, item.UnitPrice)
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to insert order item: %v", err)
+		}
+	}
+	return order, nil
+}
+
+// priceOrder builds a pending order for books from the catalog entries of
+// the ordered books, checking that they exist, that their prices match the
+// agreed ones when checkPrices is set, and that they are in stock. It is
+// shared by the repositories so that they reject orders alike.
+func priceOrder(userID string, books []BookOrder, catalog map[string]Book, checkPrices bool, taxRateBPS int) (Order, error) {
+	order := Order{UserID: userID, Status: OrderPending, Items: make([]BookOrder, 0, len(books))}
+	var changed, short []string
+	for _, item := range books {
+		book, ok := catalog[item.BookID]
+		if !ok {
+			return Order{}, fmt.Errorf("%w: %s", ErrBookNotFound, item.BookID)
+		}
+		if checkPrices && book.Price != item.UnitPrice {
+			changed = append(changed, item.BookID)
+		}
+		if book.Stock < item.Quantity {
+			short = append(short, item.BookID)
+		}
+		item.Title = book.Title
+		item.UnitPrice = book.Price
+		item.LineTotal = book.Price.Times(item.Quantity)
+		order.Subtotal += item.LineTotal
+		order.Items = append(order.Items, item)
+	}
+	if len(changed) > 0 {
+		return Order{}, &PriceChangedError{BookIDs: changed}
+	}
+	if len(short) > 0 {
+		return Order{}, &InsufficientStockError{BookIDs: short}
+	}
+	order.Tax = order.Subtotal.BasisPoints(taxRateBPS)
+	order.Total = order.Subtotal + order.Tax
+	return order, nil
+}
+
+func (r *repository) taxRateBPS() int {
+	if cfg := r.app.Config(); cfg != nil {
+		return cfg.TaxRateBPS
+	}
+	return 0
+}
+
+// mergeBookOrders combines lines for the same book, keeping first-seen order.
+func mergeBookOrders(books []BookOrder) []BookOrder {
+	merged := make([]BookOrder, 0, len(books))
+	index := make(map[string]int, len(books))
+	for _, book := range books {
+		if i, ok := index[book.BookID]; ok {
+			merged[i].Quantity += book.Quantity
+			continue
+		}
+		index[book.BookID] = len(merged)
+		merged = append(merged, book)
+	}
+	return merged
 }
 
 func (r *repository) GetOrderHistory(ctx context.Context, userID string) ([]Order, error) {
+	return r.findOrders(ctx, "o.user_id = $1", userID)
+}
 
-	query := `
-        SELECT o.id, o.user_id, oi.book_id, oi.quantity, b.title
-        FROM orders o
-        JOIN order_items oi ON o.id = oi.order_id
-        JOIN books b ON oi.book_id = b.id
-        WHERE o.user_id = $1
-    `
+func (r *repository) GetBookByID(ctx context.Context, bookID string) (Book, error) {
+	query := "SELECT " + bookColumns("") + " FROM books WHERE id = $1"
 
-	rows, err := r.db.db.QueryContext(ctx, query, userID)
+	var row bookRow
+	err := r.db.db.QueryRowContext(ctx, query, bookID).Scan(row.dest()...)
 	if err != nil {
-		return nil, err
+		if err == sql.ErrNoRows {
+			return Book{}, ErrBookNotFound
+		}
+		return Book{}, fmt.Errorf("failed to fetch book details: %v", err)
 	}
-	defer rows.Close()
 
-	orderMap := make(map[string]*Order)
+	return row.toBook(), nil
+}
 
+// GetBooksByIDs fetches several books in one query. Unknown IDs are skipped,
+// so the result may be shorter than bookIDs and is ordered by ID.
+func (r *repository) GetBooksByIDs(ctx context.Context, bookIDs []string) ([]Book, error) {
+	query := "SELECT " + bookColumns("") + " FROM books WHERE id = ANY($1::bigint[]) ORDER BY id"
+	rows, err := r.db.db.QueryContext(ctx, query, pq.Array(bookIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch books: %v", err)
+	}
+	defer rows.Close()
+
+	books := make([]Book, 0, len(bookIDs))
 	for rows.Next() {
-		var orderID, bookID, title string
-		var quantity int
-		err := rows.Scan(&orderID, &userID, &bookID, &quantity, &title)
-		if err != nil {
+		var row bookRow
+		if err := rows.Scan(row.dest()...); err != nil {
 			return nil, err
 		}
-		if _, ok := orderMap[orderID]; !ok {
-			orderMap[orderID] = &Order{
-				ID:     orderID,
-				UserID: userID,
-				Items:  make([]BookOrder, 0),
-			}
-		}
-		orderMap[orderID].Items = append(orderMap[orderID].Items, BookOrder{
-			BookID:   bookID,
-			Quantity: quantity,
-			Title:    title,
-		})
+		books = append(books, row.toBook())
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	return books, nil
+}
 
-	var orders []Order
-	for _, order := range orderMap {
-		orders = append(orders, *order)
+func (r *repository) CreateBook(ctx context.Context, book Book) (Book, error) {
+	query := "INSERT INTO books (title, author, description, price_cents, stock) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at"
+	var id int64
+	err := r.db.db.QueryRowContext(ctx, query, book.Title, book.Author, book.Description, book.Price, book.Stock).Scan(&id, &book.CreatedAt)
+	if err != nil {
+		return Book{}, fmt.Errorf("failed to create book: %v", err)
 	}
+	book.ID = formatID(id)
+	return book, nil
+}
 
-	return orders, nil
+func (r *repository) UpdateBook(ctx context.Context, book Book) (Book, error) {
+	query := "UPDATE books SET title = $1, author = $2, description = $3, price_cents = $4, stock = $5 WHERE id = $6"
+	res, err := r.db.db.ExecContext(ctx, query, book.Title, book.Author, book.Description, book.Price, book.Stock, book.ID)
+	if err != nil {
+		return Book{}, fmt.Errorf("failed to update book: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Book{}, ErrBookNotFound
+	}
+	return book, nil
 }
 
-func (r *repository) GetBookByID(ctx context.Context, bookID string) (Book, error) {
-	query := "SELECT id, title, author, description, price FROM books WHERE id = $1"
+// PatchBook sets only the columns patch supplies in a single statement and
+// returns the resulting book.
+func (r *repository) PatchBook(ctx context.Context, bookID string, patch BookPatch) (Book, error) {
+	var set []string
+	var args []any
+	column := func(name string, v any) {
+		args = append(args, v)
+		set = append(set, fmt.Sprintf("%s = $%d", name, len(args)))
+	}
+	if patch.Title != nil {
+		column("title", *patch.Title)
+	}
+	if patch.Author != nil {
+		column("author", *patch.Author)
+	}
+	if patch.Description != nil {
+		column("description", *patch.Description)
+	}
+	if patch.Price != nil {
+		column("price_cents", *patch.Price)
+	}
+	if patch.Stock != nil {
+		column("stock", *patch.Stock)
+	}
+	if len(set) == 0 {
+		return r.GetBookByID(ctx, bookID)
+	}
+	args = append(args, bookID)
+	query := fmt.Sprintf("UPDATE books SET %s WHERE id = $%d RETURNING %s", strings.Join(set, ", "), len(args), bookColumns(""))
 
-	var book Book
-	err := r.db.db.QueryRowContext(ctx, query, bookID).Scan(&book.ID, &book.Title, &book.Author, &book.Description, &book.Price)
+	var row bookRow
+	err := r.db.db.QueryRowContext(ctx, query, args...).Scan(row.dest()...)
 	if err != nil {
-		return Book{}, fmt.Errorf("failed to fetch book details: %v", err)
+		if err == sql.ErrNoRows {
+			return Book{}, ErrBookNotFound
+		}
+		return Book{}, fmt.Errorf("failed to patch book: %v", err)
 	}
+	return row.toBook(), nil
+}
 
-	return book, nil
+func (r *repository) DeleteBook(ctx context.Context, bookID string) error {
+	res, err := r.db.db.ExecContext(ctx, "DELETE FROM books WHERE id = $1", bookID)
+	if err != nil {
+		if pqErrorCode(err) == foreignKeyViolation {
+			return ErrBookInUse.Wrap(err)
+		}
+		return fmt.Errorf("failed to delete book: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrBookNotFound
+	}
+	return nil
 }
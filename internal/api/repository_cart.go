@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// GetCart returns the user's cart lines joined with the current catalog.
+func (r *repository) GetCart(ctx context.Context, userID string) (Cart, error) {
+	query := `
+        SELECT ci.book_id, b.title, ci.quantity, b.price_cents,
+               b.price_cents <> ci.unit_price_cents, b.stock >= ci.quantity
+        FROM cart_items ci
+        JOIN books b ON b.id = ci.book_id
+        WHERE ci.user_id = $1
+        ORDER BY ci.added_at, ci.book_id
+    `
+	rows, err := r.db.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %v", err)
+	}
+	defer rows.Close()
+
+	cart := Cart{Items: make([]CartItem, 0)}
+	for rows.Next() {
+		var row cartItemRow
+		err := rows.Scan(&row.BookID, &row.Title, &row.Quantity, &row.PriceCents, &row.PriceChanged, &row.InStock)
+		if err != nil {
+			return Cart{}, err
+		}
+		item := row.toCartItem()
+		cart.Subtotal += item.LineTotal
+		cart.Items = append(cart.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return Cart{}, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	return cart, nil
+}
+
+// AddCartItem adds quantity copies of a book to the cart, merging with an
+// existing line for the same book.
+func (r *repository) AddCartItem(ctx context.Context, userID, bookID string, quantity int) error {
+	query := `
+        INSERT INTO cart_items (user_id, book_id, quantity, unit_price_cents)
+        SELECT $1, id, $3, price_cents FROM books WHERE id = $2
+        ON CONFLICT (user_id, book_id)
+        DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity,
+                      unit_price_cents = EXCLUDED.unit_price_cents
+    `
+	res, err := r.db.db.ExecContext(ctx, query, userID, bookID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to add cart item: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrBookNotFound
+	}
+	return nil
+}
+
+// ReplaceCart overwrites the cart with items, which must not repeat a book.
+func (r *repository) ReplaceCart(ctx context.Context, userID string, items []BookOrder) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to clear cart: %v", err)
+	}
+	query := `
+        INSERT INTO cart_items (user_id, book_id, quantity, unit_price_cents)
+        SELECT $1, id, $3, price_cents FROM books WHERE id = $2
+    `
+	for _, item := range items {
+		res, err := tx.ExecContext(ctx, query, userID, item.BookID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to add cart item: %v", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("%w: %s", ErrBookNotFound, item.BookID)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// RemoveCartItems deletes the lines for bookIDs from the cart.
+func (r *repository) RemoveCartItems(ctx context.Context, userID string, bookIDs []string) error {
+	query := "DELETE FROM cart_items WHERE user_id = $1 AND book_id = ANY($2::bigint[])"
+	if _, err := r.db.db.ExecContext(ctx, query, userID, pq.Array(bookIDs)); err != nil {
+		return fmt.Errorf("failed to remove cart items: %v", err)
+	}
+	return nil
+}
+
+// RefreshCartPrices records the current catalog prices on the user's cart
+// lines, acknowledging any price changes.
+func (r *repository) RefreshCartPrices(ctx context.Context, userID string) error {
+	query := `
+        UPDATE cart_items ci SET unit_price_cents = b.price_cents
+        FROM books b
+        WHERE b.id = ci.book_id AND ci.user_id = $1
+    `
+	if _, err := r.db.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to refresh cart prices: %v", err)
+	}
+	return nil
+}
+
+// CheckoutCart orders the contents of the user's cart and takes the ordered
+// quantities out of it in one transaction. The cart lines are locked first,
+// so lines added meanwhile wait and are kept, and the prices the user agreed
+// to are compared with the book prices under the order's lock: if any
+// changed the order is refused with a *PriceChangedError and nothing is
+// written.
+func (r *repository) CheckoutCart(ctx context.Context, userID string) (Order, error) {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        SELECT book_id, quantity, unit_price_cents
+        FROM cart_items
+        WHERE user_id = $1
+        ORDER BY added_at, book_id
+        FOR UPDATE
+    `
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to lock cart: %v", err)
+	}
+	var lines []BookOrder
+	for rows.Next() {
+		var bookID, priceCents int64
+		var line BookOrder
+		if err := rows.Scan(&bookID, &line.Quantity, &priceCents); err != nil {
+			rows.Close()
+			return Order{}, err
+		}
+		line.BookID = formatID(bookID)
+		line.UnitPrice = Money(priceCents)
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Order{}, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	if len(lines) == 0 {
+		return Order{}, ErrCartEmpty
+	}
+
+	order, err := r.placeOrder(ctx, tx, userID, lines, true)
+	if err != nil {
+		return Order{}, err
+	}
+	if err := takeFromCart(ctx, tx, userID, order.Items); err != nil {
+		return Order{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return order, nil
+}
+
+// takeFromCart removes the ordered quantities from the cart, deleting the
+// lines that are used up.
+func takeFromCart(ctx context.Context, tx *sql.Tx, userID string, items []BookOrder) error {
+	for _, item := range items {
+		query := "DELETE FROM cart_items WHERE user_id = $1 AND book_id = $2 AND quantity <= $3"
+		if _, err := tx.ExecContext(ctx, query, userID, item.BookID, item.Quantity); err != nil {
+			return fmt.Errorf("failed to clear cart: %v", err)
+		}
+		query = "UPDATE cart_items SET quantity = quantity - $3 WHERE user_id = $1 AND book_id = $2 AND quantity > $3"
+		if _, err := tx.ExecContext(ctx, query, userID, item.BookID, item.Quantity); err != nil {
+			return fmt.Errorf("failed to clear cart: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bookstore/internal/metrics"
+	"bookstore/internal/tracing"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedRepository traces every call to the Repository it wraps and
+// records its latency and internal errors as metrics, labelled by method
+// name. Errors the caller is responsible for, such as unknown books, are
+// not counted, as they do not fail the span.
+type instrumentedRepository struct {
+	next    Repository
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+}
+
+// NewInstrumentedRepository wraps next so that its calls are traced with tp
+// and exported as metrics.
+func NewInstrumentedRepository(next Repository, m *metrics.Metrics, tp trace.TracerProvider) Repository {
+	return instrumentedRepository{next: next, metrics: m, tracer: tp.Tracer(tracing.InstrumentationName)}
+}
+
+// begin starts the span of a call to method. The returned function is
+// deferred with the named error result, so that it sees the value the
+// method returns.
+func (r instrumentedRepository) begin(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := r.tracer.Start(ctx, "Repository."+method)
+	return ctx, func(err error) {
+		r.metrics.RepositoryQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if tracing.Failed(err) {
+			r.metrics.RepositoryErrors.WithLabelValues(method).Inc()
+		}
+		tracing.End(span, err)
+	}
+}
+
+func (r instrumentedRepository) GetAllBooks(ctx context.Context, query BookQuery) (_ BookPage, err error) {
+	ctx, done := r.begin(ctx, "GetAllBooks")
+	defer func() { done(err) }()
+	return r.next.GetAllBooks(ctx, query)
+}
+
+func (r instrumentedRepository) SearchBooks(ctx context.Context, search BookSearch) (_ []BookSearchResult, err error) {
+	ctx, done := r.begin(ctx, "SearchBooks")
+	defer func() { done(err) }()
+	return r.next.SearchBooks(ctx, search)
+}
+
+func (r instrumentedRepository) PlaceOrder(ctx context.Context, email string, books []BookOrder) (_ Order, err error) {
+	ctx, done := r.begin(ctx, "PlaceOrder")
+	defer func() { done(err) }()
+	return r.next.PlaceOrder(ctx, email, books)
+}
+
+func (r instrumentedRepository) CheckoutCart(ctx context.Context, userID string) (_ Order, err error) {
+	ctx, done := r.begin(ctx, "CheckoutCart")
+	defer func() { done(err) }()
+	return r.next.CheckoutCart(ctx, userID)
+}
+
+func (r instrumentedRepository) CreateAccount(ctx context.Context, email, password string) (err error) {
+	ctx, done := r.begin(ctx, "CreateAccount")
+	defer func() { done(err) }()
+	return r.next.CreateAccount(ctx, email, password)
+}
+
+func (r instrumentedRepository) GetOrderHistory(ctx context.Context, email string) (_ []Order, err error) {
+	ctx, done := r.begin(ctx, "GetOrderHistory")
+	defer func() { done(err) }()
+	return r.next.GetOrderHistory(ctx, email)
+}
+
+func (r instrumentedRepository) GetUserIDByEmail(ctx context.Context, email string) (_ string, err error) {
+	ctx, done := r.begin(ctx, "GetUserIDByEmail")
+	defer func() { done(err) }()
+	return r.next.GetUserIDByEmail(ctx, email)
+}
+
+func (r instrumentedRepository) GetBookByID(ctx context.Context, bookID string) (_ Book, err error) {
+	ctx, done := r.begin(ctx, "GetBookByID")
+	defer func() { done(err) }()
+	return r.next.GetBookByID(ctx, bookID)
+}
+
+func (r instrumentedRepository) GetBooksByIDs(ctx context.Context, bookIDs []string) (_ []Book, err error) {
+	ctx, done := r.begin(ctx, "GetBooksByIDs")
+	defer func() { done(err) }()
+	return r.next.GetBooksByIDs(ctx, bookIDs)
+}
+
+func (r instrumentedRepository) GetUserByEmail(ctx context.Context, email string) (_ User, err error) {
+	ctx, done := r.begin(ctx, "GetUserByEmail")
+	defer func() { done(err) }()
+	return r.next.GetUserByEmail(ctx, email)
+}
+
+func (r instrumentedRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) (err error) {
+	ctx, done := r.begin(ctx, "UpdatePassword")
+	defer func() { done(err) }()
+	return r.next.UpdatePassword(ctx, userID, passwordHash)
+}
+
+func (r instrumentedRepository) SetUserRole(ctx context.Context, userID string, role Role) (err error) {
+	ctx, done := r.begin(ctx, "SetUserRole")
+	defer func() { done(err) }()
+	return r.next.SetUserRole(ctx, userID, role)
+}
+
+func (r instrumentedRepository) GetUserByID(ctx context.Context, userID string) (_ User, err error) {
+	ctx, done := r.begin(ctx, "GetUserByID")
+	defer func() { done(err) }()
+	return r.next.GetUserByID(ctx, userID)
+}
+
+func (r instrumentedRepository) CreateBook(ctx context.Context, book Book) (_ Book, err error) {
+	ctx, done := r.begin(ctx, "CreateBook")
+	defer func() { done(err) }()
+	return r.next.CreateBook(ctx, book)
+}
+
+func (r instrumentedRepository) UpdateBook(ctx context.Context, book Book) (_ Book, err error) {
+	ctx, done := r.begin(ctx, "UpdateBook")
+	defer func() { done(err) }()
+	return r.next.UpdateBook(ctx, book)
+}
+
+func (r instrumentedRepository) PatchBook(ctx context.Context, bookID string, patch BookPatch) (_ Book, err error) {
+	ctx, done := r.begin(ctx, "PatchBook")
+	defer func() { done(err) }()
+	return r.next.PatchBook(ctx, bookID, patch)
+}
+
+func (r instrumentedRepository) DeleteBook(ctx context.Context, bookID string) (err error) {
+	ctx, done := r.begin(ctx, "DeleteBook")
+	defer func() { done(err) }()
+	return r.next.DeleteBook(ctx, bookID)
+}
+
+func (r instrumentedRepository) GetCart(ctx context.Context, userID string) (_ Cart, err error) {
+	ctx, done := r.begin(ctx, "GetCart")
+	defer func() { done(err) }()
+	return r.next.GetCart(ctx, userID)
+}
+
+func (r instrumentedRepository) AddCartItem(ctx context.Context, userID, bookID string, quantity int) (err error) {
+	ctx, done := r.begin(ctx, "AddCartItem")
+	defer func() { done(err) }()
+	return r.next.AddCartItem(ctx, userID, bookID, quantity)
+}
+
+func (r instrumentedRepository) ReplaceCart(ctx context.Context, userID string, items []BookOrder) (err error) {
+	ctx, done := r.begin(ctx, "ReplaceCart")
+	defer func() { done(err) }()
+	return r.next.ReplaceCart(ctx, userID, items)
+}
+
+func (r instrumentedRepository) RemoveCartItems(ctx context.Context, userID string, bookIDs []string) (err error) {
+	ctx, done := r.begin(ctx, "RemoveCartItems")
+	defer func() { done(err) }()
+	return r.next.RemoveCartItems(ctx, userID, bookIDs)
+}
+
+func (r instrumentedRepository) RefreshCartPrices(ctx context.Context, userID string) (err error) {
+	ctx, done := r.begin(ctx, "RefreshCartPrices")
+	defer func() { done(err) }()
+	return r.next.RefreshCartPrices(ctx, userID)
+}
+
+func (r instrumentedRepository) GetOrder(ctx context.Context, orderID string) (_ Order, err error) {
+	ctx, done := r.begin(ctx, "GetOrder")
+	defer func() { done(err) }()
+	return r.next.GetOrder(ctx, orderID)
+}
+
+func (r instrumentedRepository) UpdateOrderStatus(ctx context.Context, update StatusUpdate) (err error) {
+	ctx, done := r.begin(ctx, "UpdateOrderStatus")
+	defer func() { done(err) }()
+	return r.next.UpdateOrderStatus(ctx, update)
+}
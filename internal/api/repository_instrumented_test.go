@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/metrics"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newRecorder returns a tracer provider that records finished spans in the
+// returned exporter as soon as they end.
+func newRecorder() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func Test_InstrumentedRepository(t *testing.T) {
+	c := context.Background()
+	m := metrics.New()
+	tp, exporter := newRecorder()
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("GetBookByID", mock.Anything, "7").Return(api.Book{ID: "7"}, nil).Once()
+	mockRepo.On("GetBookByID", mock.Anything, "8").Return(api.Book{}, api.ErrBookNotFound).Once()
+	mockRepo.On("DeleteBook", mock.Anything, "7").Return(errors.New("connection refused")).Once()
+	repo := api.NewInstrumentedRepository(mockRepo, m, tp)
+
+	book, err := repo.GetBookByID(c, "7")
+	assert.NoError(t, err)
+	assert.Equal(t, "7", book.ID)
+	_, err = repo.GetBookByID(c, "8")
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+	assert.Error(t, repo.DeleteBook(c, "7"))
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.RepositoryErrors.WithLabelValues("GetBookByID")), "client errors are not counted")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RepositoryErrors.WithLabelValues("DeleteBook")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.RepositoryQueryDuration), "one series per method")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "Repository.GetBookByID", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code, "client errors do not fail the span")
+	assert.Contains(t, spans[1].Attributes, attribute.String("error.code", "book_not_found"))
+	assert.Equal(t, "Repository.DeleteBook", spans[2].Name)
+	assert.Equal(t, codes.Error, spans[2].Status.Code)
+}
@@ -0,0 +1,584 @@
+package api
+
+import (
+	"bookstore/internal/application"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryRepository is a Repository that keeps everything in process memory,
+// for development and tests without Postgres. A single lock serialises
+// writers, so every method is atomic and PlaceOrder, ReplaceCart and
+// UpdateOrderStatus either apply completely or not at all, as their
+// transactions do in Postgres.
+//
+// It follows the Postgres repository closely, with these differences: text
+// is sorted by byte value, as in the C collation, search matches whole words
+// instead of stemmed lexemes (see BookIndex), and references to unknown
+// users are not rejected.
+type memoryRepository struct {
+	app *application.Application
+
+	mu     sync.RWMutex
+	lastID int64
+	users  map[string]User
+	books  map[string]Book
+	orders map[string]Order
+	// carts maps user IDs to their lines by book ID.
+	carts map[string]map[string]memoryCartLine
+	// cartWrites counts the writes that added cart lines. It stands in for
+	// the added_at column, which is the same for lines added by one
+	// statement and increases between statements.
+	cartWrites int64
+}
+
+type memoryCartLine struct {
+	quantity  int
+	unitPrice Money
+	added     int64
+}
+
+// NewMemoryRepository returns an empty in-memory Repository. Orders are
+// taxed at the rate configured on app, like NewRepository.
+func NewMemoryRepository(app *application.Application) Repository {
+	return &memoryRepository{
+		app:    app,
+		users:  make(map[string]User),
+		books:  make(map[string]Book),
+		orders: make(map[string]Order),
+		carts:  make(map[string]map[string]memoryCartLine),
+	}
+}
+
+// nextID returns the next value of the ID sequence shared by every table.
+// Like a bigserial, it is never reused.
+func (r *memoryRepository) nextID() string {
+	r.lastID++
+	return formatID(r.lastID)
+}
+
+// timestampNow returns the current time at the microsecond precision of a
+// timestamptz column.
+func timestampNow() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+func (r *memoryRepository) CreateAccount(ctx context.Context, email, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return ErrEmailTaken
+		}
+	}
+	id := r.nextID()
+	r.users[id] = User{ID: id, Email: email, PasswordHash: passwordHash, Role: RoleCustomer}
+	return nil
+}
+
+func (r *memoryRepository) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return User{}, nil
+}
+
+// GetUserByID leaves out the password hash, like the Postgres query does.
+func (r *memoryRepository) GetUserByID(ctx context.Context, userID string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u := r.users[userID]
+	u.PasswordHash = ""
+	return u, nil
+}
+
+func (r *memoryRepository) GetUserIDByEmail(ctx context.Context, email string) (string, error) {
+	u, _ := r.GetUserByEmail(ctx, email)
+	if u.ID == "" {
+		return "", ErrUserNotFound
+	}
+	return u.ID, nil
+}
+
+func (r *memoryRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.PasswordHash = passwordHash
+		r.users[userID] = u
+	}
+	return nil
+}
+
+func (r *memoryRepository) SetUserRole(ctx context.Context, userID string, role Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	u.Role = role
+	r.users[userID] = u
+	return nil
+}
+
+func (r *memoryRepository) GetAllBooks(ctx context.Context, query BookQuery) (BookPage, error) {
+	var after func(Book) bool
+	if query.After != nil {
+		after = bookAfter(query, *query.After)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	page := BookPage{Items: make([]Book, 0)}
+	var books []Book
+	for _, b := range r.books {
+		if !matchesBookQuery(query, b) {
+			continue
+		}
+		page.Total++
+		if after == nil || after(b) {
+			books = append(books, b)
+		}
+	}
+	slices.SortFunc(books, func(a, b Book) int {
+		c := compareBooks(query.Sort, a, b)
+		if query.Desc {
+			return -c
+		}
+		return c
+	})
+	// A query that was not normalized may carry no limit; like Postgres,
+	// that yields an empty page rather than a panic.
+	limit := max(query.Limit, 0)
+	if len(books) > limit {
+		if limit > 0 {
+			page.NextCursor = bookCursorAt(query, books[limit-1]).Encode()
+		}
+		books = books[:limit]
+	}
+	page.Items = append(page.Items, books...)
+	return page, nil
+}
+
+func matchesBookQuery(query BookQuery, b Book) bool {
+	switch {
+	case query.Author != "" && !strings.EqualFold(b.Author, query.Author):
+		return false
+	case query.MinPrice != nil && b.Price < *query.MinPrice:
+		return false
+	case query.MaxPrice != nil && b.Price > *query.MaxPrice:
+		return false
+	case query.InStock != nil && *query.InStock != (b.Stock > 0):
+		return false
+	}
+	return true
+}
+
+// compareBooks orders books by the sort column, then by ID.
+func compareBooks(sort BookSort, a, b Book) int {
+	var c int
+	switch sort {
+	case SortByAuthor:
+		c = strings.Compare(a.Author, b.Author)
+	case SortByPrice:
+		c = cmp.Compare(a.Price, b.Price)
+	case SortByCreated:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	default:
+		c = strings.Compare(a.Title, b.Title)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(numericID(a.ID), numericID(b.ID))
+}
+
+// bookAfter returns a predicate reporting whether a book comes after the
+// cursor in the query's order. Normalize has checked that the cursor value
+// parses as the sort column's type.
+func bookAfter(query BookQuery, cursor BookCursor) func(Book) bool {
+	at := Book{ID: formatID(cursor.ID)}
+	switch query.Sort {
+	case SortByAuthor:
+		at.Author = cursor.Value
+	case SortByPrice:
+		cents, _ := strconv.ParseInt(cursor.Value, 10, 64)
+		at.Price = Money(cents)
+	case SortByCreated:
+		at.CreatedAt, _ = time.Parse(time.RFC3339Nano, cursor.Value)
+	default:
+		at.Title = cursor.Value
+	}
+	return func(b Book) bool {
+		c := compareBooks(query.Sort, b, at)
+		return (c > 0 && !query.Desc) || (c < 0 && query.Desc)
+	}
+}
+
+// numericID parses an ID produced by formatID so that IDs sort numerically,
+// as the bigint columns do.
+func numericID(id string) int64 {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return n
+}
+
+// SearchBooks ranks a snapshot of the catalog with a BookIndex.
+func (r *memoryRepository) SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error) {
+	r.mu.RLock()
+	books := make([]Book, 0, len(r.books))
+	for _, b := range r.books {
+		books = append(books, b)
+	}
+	r.mu.RUnlock()
+	return NewBookIndex(books).SearchBooks(ctx, search)
+}
+
+func (r *memoryRepository) GetBookByID(ctx context.Context, bookID string) (Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.books[bookID]
+	if !ok {
+		return Book{}, ErrBookNotFound
+	}
+	return b, nil
+}
+
+func (r *memoryRepository) GetBooksByIDs(ctx context.Context, bookIDs []string) ([]Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	books := make([]Book, 0, len(bookIDs))
+	for _, id := range bookIDs {
+		if b, ok := r.books[id]; ok && !slices.ContainsFunc(books, func(x Book) bool { return x.ID == id }) {
+			books = append(books, b)
+		}
+	}
+	slices.SortFunc(books, func(a, b Book) int { return cmp.Compare(numericID(a.ID), numericID(b.ID)) })
+	return books, nil
+}
+
+func (r *memoryRepository) CreateBook(ctx context.Context, book Book) (Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	book.ID = r.nextID()
+	book.CreatedAt = timestampNow()
+	r.books[book.ID] = book
+	return book, nil
+}
+
+// UpdateBook keeps the stored creation time and, like the Postgres version,
+// returns book as given.
+func (r *memoryRepository) UpdateBook(ctx context.Context, book Book) (Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.books[book.ID]
+	if !ok {
+		return Book{}, ErrBookNotFound
+	}
+	stored.Title = book.Title
+	stored.Author = book.Author
+	stored.Description = book.Description
+	stored.Price = book.Price
+	stored.Stock = book.Stock
+	r.books[book.ID] = stored
+	return book, nil
+}
+
+func (r *memoryRepository) PatchBook(ctx context.Context, bookID string, patch BookPatch) (Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	book, ok := r.books[bookID]
+	if !ok {
+		return Book{}, ErrBookNotFound
+	}
+	if patch.Title != nil {
+		book.Title = *patch.Title
+	}
+	if patch.Author != nil {
+		book.Author = *patch.Author
+	}
+	if patch.Description != nil {
+		book.Description = *patch.Description
+	}
+	if patch.Price != nil {
+		book.Price = *patch.Price
+	}
+	if patch.Stock != nil {
+		book.Stock = *patch.Stock
+	}
+	r.books[bookID] = book
+	return book, nil
+}
+
+// DeleteBook refuses to delete ordered books and removes the book from every
+// cart, as the foreign keys do in Postgres.
+func (r *memoryRepository) DeleteBook(ctx context.Context, bookID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.books[bookID]; !ok {
+		return ErrBookNotFound
+	}
+	for _, o := range r.orders {
+		if slices.ContainsFunc(o.Items, func(item BookOrder) bool { return item.BookID == bookID }) {
+			return ErrBookInUse
+		}
+	}
+	delete(r.books, bookID)
+	for _, cart := range r.carts {
+		delete(cart, bookID)
+	}
+	return nil
+}
+
+// PlaceOrder checks every line before changing anything, so a rejected order
+// leaves stock untouched.
+func (r *memoryRepository) PlaceOrder(ctx context.Context, userID string, books []BookOrder) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.placeOrder(userID, mergeBookOrders(books), false)
+}
+
+// placeOrder is PlaceOrder for books that do not repeat a book. r.mu must be
+// held for writing.
+func (r *memoryRepository) placeOrder(userID string, books []BookOrder, checkPrices bool) (Order, error) {
+	order, err := priceOrder(userID, books, r.books, checkPrices, r.taxRateBPS())
+	if err != nil {
+		return Order{}, err
+	}
+	for _, item := range order.Items {
+		book := r.books[item.BookID]
+		book.Stock -= item.Quantity
+		r.books[item.BookID] = book
+	}
+	order.ID = r.nextID()
+	order.CreatedAt = timestampNow()
+	order.History = []OrderStatusChange{{To: order.Status, ChangedBy: userID, ChangedAt: order.CreatedAt}}
+	r.orders[order.ID] = order
+	// The caller gets the items in the order requested, as from Postgres.
+	order.Items = slices.Clone(order.Items)
+	order.History = slices.Clone(order.History)
+	return order, nil
+}
+
+// CheckoutCart orders the cart at the prices the user agreed to and takes
+// the ordered quantities out of it, or changes nothing.
+func (r *memoryRepository) CheckoutCart(ctx context.Context, userID string) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := make([]BookOrder, 0, len(r.carts[userID]))
+	for _, l := range r.sortedCart(userID) {
+		lines = append(lines, BookOrder{BookID: l.bookID, Quantity: l.quantity, UnitPrice: l.unitPrice})
+	}
+	if len(lines) == 0 {
+		return Order{}, ErrCartEmpty
+	}
+	order, err := r.placeOrder(userID, lines, true)
+	if err != nil {
+		return Order{}, err
+	}
+	cart := r.carts[userID]
+	for _, item := range order.Items {
+		line := cart[item.BookID]
+		line.quantity -= item.Quantity
+		if line.quantity <= 0 {
+			delete(cart, item.BookID)
+		} else {
+			cart[item.BookID] = line
+		}
+	}
+	return order, nil
+}
+
+func (r *memoryRepository) taxRateBPS() int {
+	if cfg := r.app.Config(); cfg != nil {
+		return cfg.TaxRateBPS
+	}
+	return 0
+}
+
+func (r *memoryRepository) GetOrder(ctx context.Context, orderID string) (Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orders[orderID]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	return r.orderView(o), nil
+}
+
+// GetOrderHistory returns the user's orders newest first.
+func (r *memoryRepository) GetOrderHistory(ctx context.Context, userID string) ([]Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []Order
+	for _, o := range r.orders {
+		if o.UserID == userID {
+			orders = append(orders, r.orderView(o))
+		}
+	}
+	slices.SortFunc(orders, func(a, b Order) int { return -cmp.Compare(numericID(a.ID), numericID(b.ID)) })
+	return orders, nil
+}
+
+// orderView copies a stored order for the caller, with its items ordered by
+// book ID and titled with the current catalog titles, as the Postgres query
+// returns them.
+func (r *memoryRepository) orderView(o Order) Order {
+	o.Items = slices.Clone(o.Items)
+	for i := range o.Items {
+		o.Items[i].Title = r.books[o.Items[i].BookID].Title
+	}
+	slices.SortFunc(o.Items, func(a, b BookOrder) int { return cmp.Compare(numericID(a.BookID), numericID(b.BookID)) })
+	o.History = slices.Clone(o.History)
+	return o
+}
+
+// UpdateOrderStatus applies update only while the order is still in
+// update.From, restocking the ordered books when asked to.
+func (r *memoryRepository) UpdateOrderStatus(ctx context.Context, update StatusUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[update.OrderID]
+	if !ok || o.Status != update.From {
+		return ErrOrderStatusConflict
+	}
+	o.Status = update.To
+	o.History = append(slices.Clip(o.History), OrderStatusChange{
+		From:      update.From,
+		To:        update.To,
+		ChangedBy: update.ActorID,
+		ChangedAt: timestampNow(),
+	})
+	r.orders[o.ID] = o
+	if update.Restock {
+		for _, item := range o.Items {
+			if book, ok := r.books[item.BookID]; ok {
+				book.Stock += item.Quantity
+				r.books[item.BookID] = book
+			}
+		}
+	}
+	return nil
+}
+
+// GetCart prices the user's cart lines at the current catalog prices, in the
+// order they were added.
+func (r *memoryRepository) GetCart(ctx context.Context, userID string) (Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	lines := r.sortedCart(userID)
+	cart := Cart{Items: make([]CartItem, 0, len(lines))}
+	for _, l := range lines {
+		book := r.books[l.bookID]
+		item := CartItem{
+			BookID:       l.bookID,
+			Title:        book.Title,
+			Quantity:     l.quantity,
+			UnitPrice:    book.Price,
+			LineTotal:    book.Price.Times(l.quantity),
+			PriceChanged: book.Price != l.unitPrice,
+			InStock:      book.Stock >= l.quantity,
+		}
+		cart.Subtotal += item.LineTotal
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, nil
+}
+
+// AddCartItem merges with an existing line for the book and records the
+// current price on it.
+func (r *memoryRepository) AddCartItem(ctx context.Context, userID, bookID string, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	book, ok := r.books[bookID]
+	if !ok {
+		return ErrBookNotFound
+	}
+	cart := r.cart(userID)
+	line, ok := cart[bookID]
+	if !ok {
+		r.cartWrites++
+		line.added = r.cartWrites
+	}
+	line.quantity += quantity
+	line.unitPrice = book.Price
+	cart[bookID] = line
+	return nil
+}
+
+// ReplaceCart leaves the cart unchanged when any of the books is unknown.
+func (r *memoryRepository) ReplaceCart(ctx context.Context, userID string, items []BookOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cartWrites++
+	cart := make(map[string]memoryCartLine, len(items))
+	for _, item := range items {
+		book, ok := r.books[item.BookID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrBookNotFound, item.BookID)
+		}
+		cart[item.BookID] = memoryCartLine{quantity: item.Quantity, unitPrice: book.Price, added: r.cartWrites}
+	}
+	r.carts[userID] = cart
+	return nil
+}
+
+func (r *memoryRepository) RemoveCartItems(ctx context.Context, userID string, bookIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range bookIDs {
+		delete(r.carts[userID], id)
+	}
+	return nil
+}
+
+func (r *memoryRepository) RefreshCartPrices(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, line := range r.carts[userID] {
+		line.unitPrice = r.books[id].Price
+		r.carts[userID][id] = line
+	}
+	return nil
+}
+
+type memoryCartEntry struct {
+	bookID string
+	memoryCartLine
+}
+
+// sortedCart returns the user's cart lines in the order they were added.
+func (r *memoryRepository) sortedCart(userID string) []memoryCartEntry {
+	var lines []memoryCartEntry
+	for id, l := range r.carts[userID] {
+		lines = append(lines, memoryCartEntry{id, l})
+	}
+	slices.SortFunc(lines, func(a, b memoryCartEntry) int {
+		if c := cmp.Compare(a.added, b.added); c != 0 {
+			return c
+		}
+		return cmp.Compare(numericID(a.bookID), numericID(b.bookID))
+	})
+	return lines
+}
+
+// cart returns the user's cart lines, creating them on first use. r.mu must
+// be held for writing.
+func (r *memoryRepository) cart(userID string) map[string]memoryCartLine {
+	cart, ok := r.carts[userID]
+	if !ok {
+		cart = make(map[string]memoryCartLine)
+		r.carts[userID] = cart
+	}
+	return cart
+}
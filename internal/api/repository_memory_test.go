@@ -0,0 +1,14 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/repotest"
+	"bookstore/internal/application"
+	"testing"
+)
+
+func Test_MemoryRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, app *application.Application) api.Repository {
+		return api.NewMemoryRepository(app)
+	})
+}
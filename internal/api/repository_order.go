@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// GetOrder returns a single order with its items and status history.
+func (r *repository) GetOrder(ctx context.Context, orderID string) (Order, error) {
+	orders, err := r.findOrders(ctx, "o.id = $1", orderID)
+	if err != nil {
+		return Order{}, err
+	}
+	if len(orders) == 0 {
+		return Order{}, ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+// UpdateOrderStatus applies a transition that the service has validated. The
+// update only matches while the order is still in update.From, so two
+// concurrent transitions cannot both succeed.
+func (r *repository) UpdateOrderStatus(ctx context.Context, update StatusUpdate) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE orders SET status = $1, updated_at = now() WHERE id = $2 AND status = $3"
+	res, err := tx.ExecContext(ctx, query, update.To, update.OrderID, update.From)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrOrderStatusConflict
+	}
+
+	query = "INSERT INTO order_status_history (order_id, from_status, to_status, changed_by) VALUES ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctx, query, update.OrderID, update.From, update.To, sql.NullString{String: update.ActorID, Valid: update.ActorID != ""})
+	if err != nil {
+		return fmt.Errorf("failed to record order status: %v", err)
+	}
+
+	if update.Restock {
+		query = `
+            UPDATE books b SET stock = b.stock + oi.quantity
+            FROM order_items oi
+            WHERE oi.order_id = $1 AND b.id = oi.book_id
+        `
+		if _, err := tx.ExecContext(ctx, query, update.OrderID); err != nil {
+			return fmt.Errorf("failed to restock books: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// findOrders loads the orders matching where, newest first, with their items
+// and status history.
+func (r *repository) findOrders(ctx context.Context, where string, arg any) ([]Order, error) {
+	query := `
+        SELECT o.id, o.user_id, o.status, o.subtotal_cents, o.tax_cents, o.total_cents, o.created_at,
+               oi.book_id, oi.quantity, oi.unit_price_cents, b.title
+        FROM orders o
+        JOIN order_items oi ON o.id = oi.order_id
+        JOIN books b ON oi.book_id = b.id
+        WHERE ` + where + `
+        ORDER BY o.id DESC, oi.book_id
+    `
+
+	rows, err := r.db.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []Order
+	orderIndex := make(map[string]int)
+
+	for rows.Next() {
+		var o orderRow
+		var item orderItemRow
+		err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.SubtotalCents, &o.TaxCents, &o.TotalCents, &o.CreatedAt,
+			&item.BookID, &item.Quantity, &item.UnitPriceCents, &item.Title)
+		if err != nil {
+			return nil, err
+		}
+		id := formatID(o.ID)
+		i, ok := orderIndex[id]
+		if !ok {
+			i = len(orders)
+			orderIndex[id] = i
+			orders = append(orders, o.toOrder())
+		}
+		orders[i].Items = append(orders[i].Items, item.toBookOrder())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	history, err := r.statusHistory(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orders[i].History = history[orders[i].ID]
+	}
+	return orders, nil
+}
+
+func (r *repository) statusHistory(ctx context.Context, orderIDs []string) (map[string][]OrderStatusChange, error) {
+	query := `
+        SELECT order_id, from_status, to_status, changed_by, changed_at
+        FROM order_status_history
+        WHERE order_id = ANY($1::bigint[])
+        ORDER BY changed_at, id
+    `
+	rows, err := r.db.db.QueryContext(ctx, query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %v", err)
+	}
+	defer rows.Close()
+
+	history := make(map[string][]OrderStatusChange)
+	for rows.Next() {
+		var row statusHistoryRow
+		if err := rows.Scan(&row.OrderID, &row.FromStatus, &row.ToStatus, &row.ChangedBy, &row.ChangedAt); err != nil {
+			return nil, err
+		}
+		orderID := formatID(row.OrderID)
+		history[orderID] = append(history[orderID], row.toStatusChange())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// SearchBooks ranks books against the weighted search column added by the
+// book_search migration: title (A), author (B) and description (C).
+func (r *repository) SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error) {
+	results := make([]BookSearchResult, 0)
+	tsquery := prefixTSQuery(search.Query)
+	if tsquery == "" {
+		return results, nil
+	}
+	query := `
+        SELECT ` + bookColumns("b") + `,
+               ts_rank(b.search, q) AS rank,
+               ts_headline('english',
+                           translate(CASE WHEN b.description = '' THEN b.title ELSE b.description END, $3, ''),
+                           q, $4) AS headline
+        FROM books b, to_tsquery('english', $1) q
+        WHERE b.search @@ q
+        ORDER BY rank DESC, b.id
+        LIMIT $2
+    `
+	options := fmt.Sprintf(`MaxFragments=2, MinWords=5, MaxWords=20, StartSel="%s", StopSel="%s"`, matchStart, matchStop)
+	rows, err := r.db.db.QueryContext(ctx, query, tsquery, search.Limit, matchStart+matchStop, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search books: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row bookRow
+		var res BookSearchResult
+		err := rows.Scan(append(row.dest(), &res.Rank, &res.Headline)...)
+		if err != nil {
+			return nil, err
+		}
+		res.Book = row.toBook()
+		res.Headline = renderHeadline(res.Headline)
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows:%v", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/repotest"
+	"bookstore/internal/application"
+	"bookstore/internal/migrations"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabaseEnv names a postgres:// URL of a database the Postgres
+// repository tests may create schemas in. They are skipped without it.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+func Test_PostgresRepository(t *testing.T) {
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skip(testDatabaseEnv + " is not set")
+	}
+	repotest.Run(t, func(t *testing.T, app *application.Application) api.Repository {
+		return api.NewRepository(app, *api.NewPostgresDB(migratedSchema(t, dsn)))
+	})
+}
+
+// migratedSchema creates a schema with the latest migrations applied, which
+// is dropped when the test ends, and returns a pool using it.
+func migratedSchema(t *testing.T, dsn string) *sql.DB {
+	ctx := context.Background()
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("repotest_%d", time.Now().UnixNano())
+	_, err = admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE") })
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	db, err := sql.Open("postgres", u.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrations.New(db)
+	require.NoError(t, err)
+	require.NoError(t, m.Up(ctx))
+	return db
+}
@@ -0,0 +1,688 @@
+// Package repotest is a conformance suite for implementations of
+// api.Repository. Every implementation runs it from its own tests, so that
+// the in-memory repository used for development keeps behaving like the
+// Postgres one the server runs on.
+package repotest
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TaxRateBPS is configured on the application handed to NewRepository.
+const TaxRateBPS = 1000
+
+// NewRepository returns an empty repository built for app. It is called once
+// per test.
+type NewRepository func(t *testing.T, app *application.Application) api.Repository
+
+// Run runs the suite against the repositories returned by newRepo.
+func Run(t *testing.T, newRepo NewRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s *store)
+	}{
+		{"Accounts", testAccounts},
+		{"Books", testBooks},
+		{"PatchBook", testPatchBook},
+		{"DeleteBook", testDeleteBook},
+		{"GetAllBooks", testGetAllBooks},
+		{"GetAllBooksPagination", testGetAllBooksPagination},
+		{"SearchBooks", testSearchBooks},
+		{"PlaceOrder", testPlaceOrder},
+		{"PlaceOrderIsAtomic", testPlaceOrderIsAtomic},
+		{"PlaceOrderConcurrently", testPlaceOrderConcurrently},
+		{"Orders", testOrders},
+		{"UpdateOrderStatus", testUpdateOrderStatus},
+		{"Cart", testCart},
+		{"ReplaceCart", testReplaceCart},
+		{"CheckoutCart", testCheckoutCart},
+		{"CheckoutCartConcurrently", testCheckoutCartConcurrently},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.NewAppMock()
+			app.Config().TaxRateBPS = TaxRateBPS
+			tt.test(t, &store{t: t, ctx: context.Background(), repo: newRepo(t, app)})
+		})
+	}
+}
+
+// unknownID is an ID no test creates.
+const unknownID = "999999999"
+
+// store wraps the repository under test with helpers that fail the test on
+// unexpected errors.
+type store struct {
+	t    *testing.T
+	ctx  context.Context
+	repo api.Repository
+}
+
+func (s *store) user(email string) string {
+	s.t.Helper()
+	require.NoError(s.t, s.repo.CreateAccount(s.ctx, email, "hash"))
+	id, err := s.repo.GetUserIDByEmail(s.ctx, email)
+	require.NoError(s.t, err)
+	return id
+}
+
+func (s *store) book(title, author string, price api.Money, stock int) api.Book {
+	s.t.Helper()
+	b, err := s.repo.CreateBook(s.ctx, api.Book{Title: title, Author: author, Price: price, Stock: stock})
+	require.NoError(s.t, err)
+	return b
+}
+
+func (s *store) get(id string) api.Book {
+	s.t.Helper()
+	b, err := s.repo.GetBookByID(s.ctx, id)
+	require.NoError(s.t, err)
+	return b
+}
+
+func (s *store) order(userID string, lines ...api.BookOrder) api.Order {
+	s.t.Helper()
+	o, err := s.repo.PlaceOrder(s.ctx, userID, lines)
+	require.NoError(s.t, err)
+	return o
+}
+
+func line(b api.Book, quantity int) api.BookOrder {
+	return api.BookOrder{BookID: b.ID, Quantity: quantity}
+}
+
+func ids(books []api.Book) []string {
+	out := make([]string, len(books))
+	for i, b := range books {
+		out[i] = b.ID
+	}
+	return out
+}
+
+func testAccounts(t *testing.T, s *store) {
+	require.NoError(t, s.repo.CreateAccount(s.ctx, "ann@example.com", "hash-1"))
+	assert.ErrorIs(t, s.repo.CreateAccount(s.ctx, "ann@example.com", "hash-2"), api.ErrEmailTaken)
+
+	u, err := s.repo.GetUserByEmail(s.ctx, "ann@example.com")
+	require.NoError(t, err)
+	assert.NotEmpty(t, u.ID)
+	assert.Equal(t, "ann@example.com", u.Email)
+	assert.Equal(t, "hash-1", u.PasswordHash)
+	assert.Equal(t, api.RoleCustomer, u.Role)
+
+	id, err := s.repo.GetUserIDByEmail(s.ctx, "ann@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, id)
+
+	byID, err := s.repo.GetUserByID(s.ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, api.User{ID: u.ID, Email: u.Email, Role: api.RoleCustomer}, byID, "the hash is not loaded by ID")
+
+	require.NoError(t, s.repo.UpdatePassword(s.ctx, u.ID, "hash-3"))
+	u, err = s.repo.GetUserByEmail(s.ctx, "ann@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "hash-3", u.PasswordHash)
+
+	require.NoError(t, s.repo.SetUserRole(s.ctx, u.ID, api.RoleAdmin))
+	byID, err = s.repo.GetUserByID(s.ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, api.RoleAdmin, byID.Role)
+	assert.ErrorIs(t, s.repo.SetUserRole(s.ctx, unknownID, api.RoleAdmin), api.ErrUserNotFound)
+
+	missing, err := s.repo.GetUserByEmail(s.ctx, "nobody@example.com")
+	require.NoError(t, err)
+	assert.Zero(t, missing)
+	missing, err = s.repo.GetUserByID(s.ctx, unknownID)
+	require.NoError(t, err)
+	assert.Zero(t, missing)
+	_, err = s.repo.GetUserIDByEmail(s.ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, api.ErrUserNotFound)
+}
+
+func testBooks(t *testing.T, s *store) {
+	before := time.Now().Add(-time.Minute)
+	created, err := s.repo.CreateBook(s.ctx, api.Book{Title: "Dune", Author: "Frank Herbert", Description: "Spice.", Price: 999, Stock: 3})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.WithinDuration(t, time.Now(), created.CreatedAt, time.Since(before))
+
+	got := s.get(created.ID)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Equal(t, "Dune", got.Title)
+	assert.Equal(t, "Frank Herbert", got.Author)
+	assert.Equal(t, "Spice.", got.Description)
+	assert.Equal(t, api.Money(999), got.Price)
+	assert.Equal(t, 3, got.Stock)
+	assert.True(t, created.CreatedAt.Equal(got.CreatedAt))
+
+	_, err = s.repo.GetBookByID(s.ctx, unknownID)
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+
+	update := got
+	update.Title, update.Price, update.Stock = "Dune Messiah", 1299, 0
+	updated, err := s.repo.UpdateBook(s.ctx, update)
+	require.NoError(t, err)
+	assert.Equal(t, update, updated)
+	got = s.get(created.ID)
+	assert.Equal(t, "Dune Messiah", got.Title)
+	assert.Equal(t, api.Money(1299), got.Price)
+	assert.Equal(t, 0, got.Stock)
+	assert.True(t, created.CreatedAt.Equal(got.CreatedAt), "updates keep the creation time")
+
+	_, err = s.repo.UpdateBook(s.ctx, api.Book{ID: unknownID, Title: "Ghost"})
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+
+	other := s.book("Emma", "Jane Austen", 500, 1)
+	books, err := s.repo.GetBooksByIDs(s.ctx, []string{other.ID, unknownID, created.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{created.ID, other.ID}, ids(books), "unknown IDs are skipped and the rest ordered by ID")
+
+	books, err = s.repo.GetBooksByIDs(s.ctx, []string{unknownID})
+	require.NoError(t, err)
+	assert.NotNil(t, books)
+	assert.Empty(t, books)
+}
+
+func testPatchBook(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	book := s.book("Dune", "Frank Herbert", 1000, 5)
+	s.order(userID, line(book, 2))
+
+	price := api.Money(1250)
+	patched, err := s.repo.PatchBook(s.ctx, book.ID, api.BookPatch{Price: &price})
+	require.NoError(t, err)
+	assert.Equal(t, api.Money(1250), patched.Price)
+	assert.Equal(t, "Dune", patched.Title)
+	assert.Equal(t, 3, patched.Stock, "stock taken by orders is kept")
+	assert.True(t, book.CreatedAt.Equal(patched.CreatedAt))
+	assert.Equal(t, patched, s.get(book.ID))
+
+	stock, title := 10, "Dune Messiah"
+	patched, err = s.repo.PatchBook(s.ctx, book.ID, api.BookPatch{Title: &title, Stock: &stock})
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", patched.Title)
+	assert.Equal(t, 10, patched.Stock)
+	assert.Equal(t, api.Money(1250), patched.Price)
+
+	unchanged, err := s.repo.PatchBook(s.ctx, book.ID, api.BookPatch{})
+	require.NoError(t, err)
+	assert.Equal(t, patched, unchanged)
+
+	_, err = s.repo.PatchBook(s.ctx, unknownID, api.BookPatch{Price: &price})
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+	_, err = s.repo.PatchBook(s.ctx, unknownID, api.BookPatch{})
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+}
+
+func testDeleteBook(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	ordered := s.book("Dune", "Frank Herbert", 999, 5)
+	carted := s.book("Emma", "Jane Austen", 500, 5)
+	s.order(userID, line(ordered, 1))
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, carted.ID, 1))
+
+	assert.ErrorIs(t, s.repo.DeleteBook(s.ctx, ordered.ID), api.ErrBookInUse)
+	s.get(ordered.ID)
+
+	require.NoError(t, s.repo.DeleteBook(s.ctx, carted.ID))
+	_, err := s.repo.GetBookByID(s.ctx, carted.ID)
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+	cart, err := s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items, "deleting a book removes it from carts")
+
+	assert.ErrorIs(t, s.repo.DeleteBook(s.ctx, carted.ID), api.ErrBookNotFound)
+}
+
+func testGetAllBooks(t *testing.T, s *store) {
+	austen1 := s.book("Emma", "Jane Austen", 800, 2)
+	austen2 := s.book("Persuasion", "Jane Austen", 1200, 0)
+	herbert := s.book("Dune", "Frank Herbert", 1000, 5)
+	woolf := s.book("Orlando", "Virginia Woolf", 1500, 1)
+
+	price := func(m api.Money) *api.Money { return &m }
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		query api.BookQuery
+		want  []string
+	}{
+		{"by title", api.BookQuery{}, []string{herbert.ID, austen1.ID, woolf.ID, austen2.ID}},
+		{"by title descending", api.BookQuery{Desc: true}, []string{austen2.ID, woolf.ID, austen1.ID, herbert.ID}},
+		{"by price", api.BookQuery{Sort: api.SortByPrice}, []string{austen1.ID, herbert.ID, austen2.ID, woolf.ID}},
+		{"by author then ID", api.BookQuery{Sort: api.SortByAuthor}, []string{herbert.ID, austen1.ID, austen2.ID, woolf.ID}},
+		{"by creation", api.BookQuery{Sort: api.SortByCreated}, []string{austen1.ID, austen2.ID, herbert.ID, woolf.ID}},
+		{"author ignores case", api.BookQuery{Author: "jane austen"}, []string{austen1.ID, austen2.ID}},
+		{"price range is inclusive", api.BookQuery{MinPrice: price(1000), MaxPrice: price(1200)}, []string{herbert.ID, austen2.ID}},
+		{"in stock", api.BookQuery{InStock: &yes}, []string{herbert.ID, austen1.ID, woolf.ID}},
+		{"out of stock", api.BookQuery{InStock: &no}, []string{austen2.ID}},
+		{"no match", api.BookQuery{Author: "Nobody"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.query.Normalize())
+			page, err := s.repo.GetAllBooks(s.ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(page.Items))
+			assert.Equal(t, len(tt.want), page.Total)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func testGetAllBooksPagination(t *testing.T, s *store) {
+	// Equal prices and authors make the ID the tie breaker.
+	var all []api.Book
+	for i := range 7 {
+		all = append(all, s.book("Book "+strconv.Itoa(i), "Author "+strconv.Itoa(i%2), api.Money(100*(i%3)), i))
+	}
+
+	for _, sort := range []api.BookSort{api.SortByTitle, api.SortByAuthor, api.SortByPrice, api.SortByCreated} {
+		for _, desc := range []bool{false, true} {
+			query := api.BookQuery{Sort: sort, Desc: desc}
+			require.NoError(t, query.Normalize())
+			first, err := s.repo.GetAllBooks(s.ctx, query)
+			require.NoError(t, err)
+			want := ids(first.Items)
+			require.Len(t, want, len(all))
+
+			var got []string
+			query.Limit = 3
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, len(all), "pagination does not terminate")
+				require.NoError(t, query.Normalize())
+				page, err := s.repo.GetAllBooks(s.ctx, query)
+				require.NoError(t, err)
+				assert.Equal(t, len(all), page.Total, "the total ignores the cursor")
+				assert.LessOrEqual(t, len(page.Items), 3)
+				got = append(got, ids(page.Items)...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			assert.Equal(t, want, got, "sort %s, desc %v", sort, desc)
+		}
+	}
+
+	// Without Normalize the limit stays zero, which yields an empty page.
+	empty, err := s.repo.GetAllBooks(s.ctx, api.BookQuery{Sort: api.SortByTitle})
+	require.NoError(t, err)
+	assert.Empty(t, empty.Items)
+	assert.Empty(t, empty.NextCursor)
+	assert.Equal(t, len(all), empty.Total)
+}
+
+func testSearchBooks(t *testing.T, s *store) {
+	inTitle := s.book("Gardens of the Moon", "Steven Erikson", 100, 1)
+	inDescription, err := s.repo.CreateBook(s.ctx, api.Book{Title: "Night Watch", Author: "Terry Pratchett", Description: "A moon rises over the city.", Price: 100})
+	require.NoError(t, err)
+	s.book("Emma", "Jane Austen", 100, 1)
+
+	results, err := s.repo.SearchBooks(s.ctx, api.BookSearch{Query: "moon", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, inTitle.ID, results[0].Book.ID, "title matches rank first")
+	assert.Equal(t, inDescription.ID, results[1].Book.ID)
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Contains(t, results[1].Headline, "<b>moon</b>")
+
+	script, err := s.repo.CreateBook(s.ctx, api.Book{Title: "Mischief", Author: "Mallory", Description: `<script>alert("x")</script> mischief & more`, Price: 100})
+	require.NoError(t, err)
+	results, err = s.repo.SearchBooks(s.ctx, api.BookSearch{Query: "mischief", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, script.ID, results[0].Book.ID)
+	assert.Contains(t, results[0].Headline, "<b>mischief</b>")
+	assert.NotContains(t, results[0].Headline, "<script", "book text is escaped")
+
+	results, err = s.repo.SearchBooks(s.ctx, api.BookSearch{Query: "gardens mo", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1, "every term must match and the last one as a prefix")
+	assert.Equal(t, inTitle.ID, results[0].Book.ID)
+
+	results, err = s.repo.SearchBooks(s.ctx, api.BookSearch{Query: "moon", Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = s.repo.SearchBooks(s.ctx, api.BookSearch{Query: "?!", Limit: 10})
+	require.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+}
+
+func testPlaceOrder(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 5)
+	emma := s.book("Emma", "Jane Austen", 250, 2)
+
+	order := s.order(userID, line(emma, 1), line(dune, 2), line(emma, 1))
+
+	assert.NotEmpty(t, order.ID)
+	assert.Equal(t, userID, order.UserID)
+	assert.Equal(t, api.OrderPending, order.Status)
+	assert.Equal(t, []api.BookOrder{
+		{BookID: emma.ID, Quantity: 2, Title: "Emma", UnitPrice: 250, LineTotal: 500},
+		{BookID: dune.ID, Quantity: 2, Title: "Dune", UnitPrice: 1000, LineTotal: 2000},
+	}, order.Items, "lines for the same book are merged")
+	assert.Equal(t, api.Money(2500), order.Subtotal)
+	assert.Equal(t, api.Money(250), order.Tax)
+	assert.Equal(t, api.Money(2750), order.Total)
+	require.Len(t, order.History, 1)
+	assert.Equal(t, api.OrderStatusChange{To: api.OrderPending, ChangedBy: userID, ChangedAt: order.History[0].ChangedAt}, order.History[0])
+	assert.True(t, order.CreatedAt.Equal(order.History[0].ChangedAt))
+
+	assert.Equal(t, 3, s.get(dune.ID).Stock)
+	assert.Equal(t, 0, s.get(emma.ID).Stock)
+
+	stored, err := s.repo.GetOrder(s.ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, order.Total, stored.Total)
+	assert.ElementsMatch(t, order.Items, stored.Items)
+
+	// Orders keep the price they were placed at.
+	update := s.get(dune.ID)
+	update.Price = 9999
+	_, err = s.repo.UpdateBook(s.ctx, update)
+	require.NoError(t, err)
+	stored, err = s.repo.GetOrder(s.ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, api.Money(2750), stored.Total)
+	for _, item := range stored.Items {
+		if item.BookID == dune.ID {
+			assert.Equal(t, api.Money(1000), item.UnitPrice)
+		}
+	}
+}
+
+func testPlaceOrderIsAtomic(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 5)
+	emma := s.book("Emma", "Jane Austen", 250, 1)
+	woolf := s.book("Orlando", "Virginia Woolf", 300, 0)
+
+	_, err := s.repo.PlaceOrder(s.ctx, userID, []api.BookOrder{line(dune, 1), line(emma, 2), line(woolf, 1)})
+	var short *api.InsufficientStockError
+	require.ErrorAs(t, err, &short)
+	assert.ElementsMatch(t, []string{emma.ID, woolf.ID}, short.BookIDs, "every short book is reported")
+
+	_, err = s.repo.PlaceOrder(s.ctx, userID, []api.BookOrder{line(dune, 1), {BookID: unknownID, Quantity: 1}})
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+
+	assert.Equal(t, 5, s.get(dune.ID).Stock, "rejected orders leave stock untouched")
+	assert.Equal(t, 1, s.get(emma.ID).Stock)
+	orders, err := s.repo.GetOrderHistory(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+func testPlaceOrderConcurrently(t *testing.T, s *store) {
+	const stock, buyers = 5, 12
+	userID := s.user("ann@example.com")
+	book := s.book("Dune", "Frank Herbert", 1000, stock)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, buyers)
+	for range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.repo.PlaceOrder(s.ctx, userID, []api.BookOrder{line(book, 1)})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	placed := 0
+	for err := range errs {
+		if err == nil {
+			placed++
+			continue
+		}
+		var short *api.InsufficientStockError
+		assert.ErrorAs(t, err, &short)
+	}
+	assert.Equal(t, stock, placed, "stock is never oversold")
+	assert.Equal(t, 0, s.get(book.ID).Stock)
+}
+
+func testOrders(t *testing.T, s *store) {
+	ann := s.user("ann@example.com")
+	bob := s.user("bob@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 10)
+	emma := s.book("Emma", "Jane Austen", 250, 10)
+
+	first := s.order(ann, line(emma, 1), line(dune, 1))
+	s.order(bob, line(dune, 1))
+	second := s.order(ann, line(emma, 2))
+
+	orders, err := s.repo.GetOrderHistory(s.ctx, ann)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, second.ID, orders[0].ID, "newest first")
+	assert.Equal(t, first.ID, orders[1].ID)
+	assert.Equal(t, []string{dune.ID, emma.ID}, []string{orders[1].Items[0].BookID, orders[1].Items[1].BookID}, "items are ordered by book ID")
+	assert.Equal(t, first.Total, orders[1].Total)
+	assert.Len(t, orders[1].History, 1)
+
+	order, err := s.repo.GetOrder(s.ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ann, order.UserID)
+	assert.Equal(t, api.OrderPending, order.Status)
+	assert.Equal(t, []api.BookOrder{{BookID: emma.ID, Quantity: 2, Title: "Emma", UnitPrice: 250, LineTotal: 500}}, order.Items)
+	assert.Equal(t, second.Subtotal, order.Subtotal)
+	assert.Equal(t, second.Tax, order.Tax)
+	assert.True(t, second.CreatedAt.Equal(order.CreatedAt))
+
+	_, err = s.repo.GetOrder(s.ctx, unknownID)
+	assert.ErrorIs(t, err, api.ErrOrderNotFound)
+
+	orders, err = s.repo.GetOrderHistory(s.ctx, s.user("cat@example.com"))
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+func testUpdateOrderStatus(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	staffID := s.user("staff@example.com")
+	book := s.book("Dune", "Frank Herbert", 1000, 5)
+	order := s.order(userID, line(book, 2))
+
+	require.NoError(t, s.repo.UpdateOrderStatus(s.ctx, api.StatusUpdate{OrderID: order.ID, From: api.OrderPending, To: api.OrderPaid, ActorID: staffID}))
+	err := s.repo.UpdateOrderStatus(s.ctx, api.StatusUpdate{OrderID: order.ID, From: api.OrderPending, To: api.OrderCancelled, ActorID: userID})
+	assert.ErrorIs(t, err, api.ErrOrderStatusConflict, "the order is no longer pending")
+	assert.Equal(t, 3, s.get(book.ID).Stock)
+
+	require.NoError(t, s.repo.UpdateOrderStatus(s.ctx, api.StatusUpdate{OrderID: order.ID, From: api.OrderPaid, To: api.OrderCancelled, Restock: true}))
+	assert.Equal(t, 5, s.get(book.ID).Stock, "cancelling restocks")
+
+	got, err := s.repo.GetOrder(s.ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, api.OrderCancelled, got.Status)
+	require.Len(t, got.History, 3)
+	for i, want := range []api.OrderStatusChange{
+		{To: api.OrderPending, ChangedBy: userID},
+		{From: api.OrderPending, To: api.OrderPaid, ChangedBy: staffID},
+		{From: api.OrderPaid, To: api.OrderCancelled},
+	} {
+		want.ChangedAt = got.History[i].ChangedAt
+		assert.Equal(t, want, got.History[i])
+		if i > 0 {
+			assert.False(t, got.History[i].ChangedAt.Before(got.History[i-1].ChangedAt))
+		}
+	}
+
+	err = s.repo.UpdateOrderStatus(s.ctx, api.StatusUpdate{OrderID: unknownID, From: api.OrderPending, To: api.OrderPaid})
+	assert.ErrorIs(t, err, api.ErrOrderStatusConflict)
+}
+
+func testCart(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	otherID := s.user("bob@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 5)
+	emma := s.book("Emma", "Jane Austen", 250, 1)
+
+	cart, err := s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.NotNil(t, cart.Items)
+	assert.Empty(t, cart.Items)
+
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, emma.ID, 1))
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, dune.ID, 1))
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, emma.ID, 1))
+	require.NoError(t, s.repo.AddCartItem(s.ctx, otherID, dune.ID, 4))
+	assert.ErrorIs(t, s.repo.AddCartItem(s.ctx, userID, unknownID, 1), api.ErrBookNotFound)
+
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, api.Cart{
+		Items: []api.CartItem{
+			{BookID: emma.ID, Title: "Emma", Quantity: 2, UnitPrice: 250, LineTotal: 500, InStock: false},
+			{BookID: dune.ID, Title: "Dune", Quantity: 1, UnitPrice: 1000, LineTotal: 1000, InStock: true},
+		},
+		Subtotal: 1500,
+	}, cart, "lines are merged and kept in the order they were added")
+
+	update := dune
+	update.Price = 1100
+	_, err = s.repo.UpdateBook(s.ctx, update)
+	require.NoError(t, err)
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, cart.Items[1].PriceChanged)
+	assert.Equal(t, api.Money(1100), cart.Items[1].UnitPrice, "carts are priced at the current price")
+	assert.Equal(t, api.Money(1600), cart.Subtotal)
+
+	require.NoError(t, s.repo.RefreshCartPrices(s.ctx, userID))
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, cart.Items[1].PriceChanged)
+	other, err := s.repo.GetCart(s.ctx, otherID)
+	require.NoError(t, err)
+	assert.True(t, other.Items[0].PriceChanged, "refreshing is per user")
+
+	require.NoError(t, s.repo.RemoveCartItems(s.ctx, userID, []string{emma.ID, unknownID}))
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{dune.ID}, []string{cart.Items[0].BookID})
+	assert.Len(t, cart.Items, 1)
+}
+
+func testReplaceCart(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 5)
+	emma := s.book("Emma", "Jane Austen", 250, 5)
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, dune.ID, 1))
+
+	err := s.repo.ReplaceCart(s.ctx, userID, []api.BookOrder{line(emma, 1), {BookID: unknownID, Quantity: 1}})
+	assert.ErrorIs(t, err, api.ErrBookNotFound)
+	cart, err := s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 1, "a failed replacement leaves the cart unchanged")
+	assert.Equal(t, dune.ID, cart.Items[0].BookID)
+
+	require.NoError(t, s.repo.ReplaceCart(s.ctx, userID, []api.BookOrder{line(emma, 3), line(dune, 2)}))
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []api.CartItem{
+		{BookID: dune.ID, Title: "Dune", Quantity: 2, UnitPrice: 1000, LineTotal: 2000, InStock: true},
+		{BookID: emma.ID, Title: "Emma", Quantity: 3, UnitPrice: 250, LineTotal: 750, InStock: true},
+	}, cart.Items, "lines added together are ordered by book ID")
+
+	require.NoError(t, s.repo.ReplaceCart(s.ctx, userID, nil))
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items)
+	assert.Zero(t, cart.Subtotal)
+}
+
+func testCheckoutCart(t *testing.T, s *store) {
+	userID := s.user("ann@example.com")
+	dune := s.book("Dune", "Frank Herbert", 1000, 5)
+	emma := s.book("Emma", "Jane Austen", 250, 1)
+
+	_, err := s.repo.CheckoutCart(s.ctx, userID)
+	assert.ErrorIs(t, err, api.ErrCartEmpty)
+
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, dune.ID, 2))
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, emma.ID, 2))
+	_, err = s.repo.CheckoutCart(s.ctx, userID)
+	var short *api.InsufficientStockError
+	require.ErrorAs(t, err, &short)
+	assert.Equal(t, []string{emma.ID}, short.BookIDs)
+
+	price := api.Money(1100)
+	_, err = s.repo.PatchBook(s.ctx, dune.ID, api.BookPatch{Price: &price})
+	require.NoError(t, err)
+	_, err = s.repo.CheckoutCart(s.ctx, userID)
+	var changed *api.PriceChangedError
+	require.ErrorAs(t, err, &changed, "the price the user agreed to is checked")
+	assert.Equal(t, []string{dune.ID}, changed.BookIDs)
+
+	assert.Equal(t, 5, s.get(dune.ID).Stock, "refused checkouts change nothing")
+	cart, err := s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 2)
+	orders, err := s.repo.GetOrderHistory(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	require.NoError(t, s.repo.RefreshCartPrices(s.ctx, userID))
+	require.NoError(t, s.repo.RemoveCartItems(s.ctx, userID, []string{emma.ID}))
+	order, err := s.repo.CheckoutCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, order.UserID)
+	assert.Equal(t, api.OrderPending, order.Status)
+	assert.Equal(t, []api.BookOrder{{BookID: dune.ID, Quantity: 2, Title: "Dune", UnitPrice: 1100, LineTotal: 2200}}, order.Items)
+	assert.Equal(t, api.Money(2420), order.Total)
+	assert.Equal(t, 3, s.get(dune.ID).Stock)
+	cart, err = s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items, "ordered lines leave the cart")
+}
+
+func testCheckoutCartConcurrently(t *testing.T, s *store) {
+	const adds = 20
+	userID := s.user("ann@example.com")
+	book := s.book("Dune", "Frank Herbert", 1000, 1000)
+	require.NoError(t, s.repo.AddCartItem(s.ctx, userID, book.ID, 1))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range adds {
+			assert.NoError(t, s.repo.AddCartItem(s.ctx, userID, book.ID, 1))
+		}
+	}()
+	ordered := 0
+	for range 5 {
+		order, err := s.repo.CheckoutCart(s.ctx, userID)
+		if errors.Is(err, api.ErrCartEmpty) {
+			continue
+		}
+		require.NoError(t, err)
+		ordered += order.Items[0].Quantity
+	}
+	wg.Wait()
+
+	cart, err := s.repo.GetCart(s.ctx, userID)
+	require.NoError(t, err)
+	inCart := 0
+	for _, item := range cart.Items {
+		inCart += item.Quantity
+	}
+	assert.Equal(t, adds+1, ordered+inCart, "copies added during checkout stay in the cart")
+	assert.Equal(t, 1000-ordered, s.get(book.ID).Stock)
+}
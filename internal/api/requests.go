@@ -0,0 +1,92 @@
+package api
+
+// Request DTOs are what handlers decode from request bodies and query
+// strings. They carry the `binding` rules checked by gin and convert to the
+// domain types with their to* methods; fields a client must not set, such as
+// IDs and owners, simply do not exist here.
+
+// LoginRequest is the body of POST /sessions. The password policy is not
+// applied here so that accounts created before it can still log in.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AccountRequest is the body of POST /accounts.
+type AccountRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+// OrderItemRequest is a line of an order or cart request.
+type OrderItemRequest struct {
+	BookID   string `json:"bookId" binding:"required,id"`
+	Quantity int    `json:"quantity" binding:"min=1,max_quantity"`
+}
+
+func (r OrderItemRequest) toBookOrder() BookOrder {
+	return BookOrder{BookID: r.BookID, Quantity: r.Quantity}
+}
+
+func toBookOrders(items []OrderItemRequest) []BookOrder {
+	books := make([]BookOrder, len(items))
+	for i, item := range items {
+		books[i] = item.toBookOrder()
+	}
+	return books
+}
+
+// OrderRequest is the body of POST /orders.
+type OrderRequest struct {
+	Items []OrderItemRequest `json:"items" binding:"required,min=1,max_items,unique_books,dive"`
+}
+
+// CartRequest is the body of PUT /cart. An empty list clears the cart.
+type CartRequest struct {
+	Items []OrderItemRequest `json:"items" binding:"max_items,dive"`
+}
+
+// BookRequest is the body of POST /books and PUT /books/:id.
+type BookRequest struct {
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Stock       int    `json:"stock"`
+}
+
+func (r BookRequest) toBook(id string) Book {
+	return Book{
+		ID:          id,
+		Title:       r.Title,
+		Author:      r.Author,
+		Description: r.Description,
+		Price:       r.Price,
+		Stock:       r.Stock,
+	}
+}
+
+// BookPatchRequest is the body of PATCH /books/:id; omitted fields are left
+// unchanged.
+type BookPatchRequest struct {
+	Title       *string `json:"title"`
+	Author      *string `json:"author"`
+	Description *string `json:"description"`
+	Price       *Money  `json:"price"`
+	Stock       *int    `json:"stock"`
+}
+
+func (r BookPatchRequest) toBookPatch() BookPatch {
+	return BookPatch{
+		Title:       r.Title,
+		Author:      r.Author,
+		Description: r.Description,
+		Price:       r.Price,
+		Stock:       r.Stock,
+	}
+}
+
+// StatusRequest is the body of POST /orders/:id/status.
+type StatusRequest struct {
+	Status OrderStatus `json:"status" binding:"required"`
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bookstore/internal/application"
+	"bookstore/internal/logging"
+	"bookstore/internal/tracing"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// APIPrefix is the path prefix of the current API version.
+const APIPrefix = "/api/v1"
+
+// The unversioned paths the API was first published under are still served
+// until legacySunset, with headers pointing clients at their /api/v1
+// successors.
+var (
+	legacyDeprecatedAt = time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	legacySunset       = time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// NewRouter builds the HTTP routes on top of service. It needs no database,
+// so tests can drive it with a mock Service.
+func NewRouter(app *application.Application, service Service) *gin.Engine {
+	doc, err := OpenAPI()
+	if err != nil {
+		panic(err)
+	}
+	r := gin.New()
+	r.Use(
+		otelgin.Middleware(tracing.ServiceName,
+			otelgin.WithTracerProvider(app.TracerProvider()),
+			otelgin.WithPropagators(tracing.Propagator),
+			otelgin.WithFilter(traced),
+		),
+		app.Metrics().Middleware(),
+		logging.Middleware(app.Logger()),
+		recovery(),
+		ErrorHandler(),
+	)
+
+	h := NewHandler(app, service)
+	requireAuth := RequireAuth(service)
+	// The probes and metrics are unversioned and unauthenticated. /health predates the
+	// split and answers like /readyz.
+	probes := app.Health()
+	r.GET("/healthz", probes.Live)
+	r.GET("/readyz", probes.Readiness)
+	r.GET("/health", deprecated("/readyz"), probes.Readiness)
+	r.GET("/metrics", gin.WrapH(app.Metrics().Handler()))
+	serveOpenAPI(r, doc)
+
+	v1 := r.Group(APIPrefix)
+	if cfg := app.Config(); cfg != nil && cfg.ValidateRequests {
+		v1.Use(ValidateRequests(doc))
+	}
+	v1.GET("/books", h.GetAllBooks)
+	v1.GET("/books/search", h.SearchBooks)
+	v1.GET("/books/:id", h.GetBookByID)
+	v1.POST("/accounts", h.CreateAccount)
+	v1.POST("/sessions", h.CreateSession)
+	v1.GET("/users", requireAuth, h.GetUserIDByEmail)
+	v1.GET("/users/me/orders", requireAuth, h.GetOrderHistory)
+
+	orders := v1.Group("/orders", requireAuth)
+	orders.POST("", h.PlaceOrder)
+	orders.POST("/:id/cancel", h.CancelOrder)
+	orders.POST("/:id/status", RequirePermission(PermManageOrders), h.TransitionOrder)
+
+	cart := v1.Group("/cart", requireAuth)
+	cart.GET("", h.GetCart)
+	cart.PUT("", h.ReplaceCart)
+	cart.POST("/items", h.AddCartItem)
+	cart.DELETE("/items/:bookId", h.RemoveCartItem)
+	cart.POST("/checkout", h.CheckoutCart)
+
+	admin := v1.Group("/books", requireAuth, RequirePermission(PermManageCatalog))
+	admin.POST("", h.CreateBook)
+	admin.PUT("/:id", h.UpdateBook)
+	admin.PATCH("/:id", h.PatchBook)
+	admin.DELETE("/:id", h.DeleteBook)
+
+	system := v1.Group("/system", requireAuth, RequirePermission(PermViewSystem))
+	system.GET("/database", h.GetDatabaseStats)
+
+	registerLegacyRoutes(r, h, requireAuth)
+	return r
+}
+
+// untracedPaths are polled by infrastructure; tracing them would drown the
+// requests of actual clients.
+var untracedPaths = map[string]bool{"/healthz": true, "/readyz": true, "/health": true, "/metrics": true}
+
+func traced(r *http.Request) bool {
+	return !untracedPaths[r.URL.Path]
+}
+
+// registerLegacyRoutes serves the pre-/api/v1 paths. Each entry names the
+// versioned route that replaces it.
+func registerLegacyRoutes(r gin.IRouter, h Handler, requireAuth gin.HandlerFunc) {
+	manageOrders := RequirePermission(PermManageOrders)
+	manageCatalog := RequirePermission(PermManageCatalog)
+	routes := []struct {
+		method    string
+		path      string
+		successor string
+		handlers  gin.HandlersChain
+	}{
+		{http.MethodGet, "/books", "/books", gin.HandlersChain{h.GetAllBooks}},
+		{http.MethodGet, "/books/search", "/books/search", gin.HandlersChain{h.SearchBooks}},
+		{http.MethodGet, "/book/", "/books/:id", gin.HandlersChain{h.GetBookByID}},
+		{http.MethodPost, "/accounts", "/accounts", gin.HandlersChain{h.CreateAccount}},
+		{http.MethodPost, "/sessions", "/sessions", gin.HandlersChain{h.CreateSession}},
+		{http.MethodGet, "/users/:email", "/users", gin.HandlersChain{requireAuth, h.GetUserIDByEmail}},
+		{http.MethodGet, "/order/history", "/users/me/orders", gin.HandlersChain{requireAuth, h.GetLegacyOrderHistory}},
+		{http.MethodPost, "/orders", "/orders", gin.HandlersChain{requireAuth, h.PlaceOrder}},
+		{http.MethodPost, "/orders/:id/cancel", "/orders/:id/cancel", gin.HandlersChain{requireAuth, h.CancelOrder}},
+		{http.MethodPost, "/orders/:id/status", "/orders/:id/status", gin.HandlersChain{requireAuth, manageOrders, h.TransitionOrder}},
+		{http.MethodGet, "/cart", "/cart", gin.HandlersChain{requireAuth, h.GetCart}},
+		{http.MethodPut, "/cart", "/cart", gin.HandlersChain{requireAuth, h.ReplaceCart}},
+		{http.MethodPost, "/cart/items", "/cart/items", gin.HandlersChain{requireAuth, h.AddCartItem}},
+		{http.MethodDelete, "/cart/items/:bookId", "/cart/items/:bookId", gin.HandlersChain{requireAuth, h.RemoveCartItem}},
+		{http.MethodPost, "/cart/checkout", "/cart/checkout", gin.HandlersChain{requireAuth, h.CheckoutCart}},
+		{http.MethodPost, "/books", "/books", gin.HandlersChain{requireAuth, manageCatalog, h.CreateBook}},
+		{http.MethodPut, "/books/:id", "/books/:id", gin.HandlersChain{requireAuth, manageCatalog, h.UpdateBook}},
+		{http.MethodPatch, "/books/:id", "/books/:id", gin.HandlersChain{requireAuth, manageCatalog, h.PatchBook}},
+		{http.MethodDelete, "/books/:id", "/books/:id", gin.HandlersChain{requireAuth, manageCatalog, h.DeleteBook}},
+	}
+	for _, route := range routes {
+		handlers := append(gin.HandlersChain{deprecated(APIPrefix + route.successor)}, route.handlers...)
+		r.Handle(route.method, route.path, handlers...)
+	}
+}
+
+// deprecated marks a response as coming from a legacy path: Deprecation and
+// Sunset follow RFC 9745 and RFC 8594, and Link names the replacement.
+func deprecated(successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", legacyDeprecatedAt.Unix())
+	sunset := legacySunset.Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunset)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, expandPath(c, successor)))
+		c.Next()
+	}
+}
+
+// expandPath fills the :params of a route pattern from the request's path
+// parameters, falling back to the query string for legacy routes that took
+// them there.
+func expandPath(c *gin.Context, pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		value := c.Param(name)
+		if value == "" {
+			value = c.Query(name)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	return strings.Join(segments, "/")
+}
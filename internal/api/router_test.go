@@ -0,0 +1,251 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"bookstore/internal/logging"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testRequestID = "test-request"
+
+// withRequestID adds the request ID that NewRouter reports in problem bodies.
+func withRequestID(body string) string {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		panic(err)
+	}
+	fields["requestId"] = testRequestID
+	data, _ := json.Marshal(fields)
+	return string(data)
+}
+
+func Test_NewRouter(t *testing.T) {
+	app := application.NewAppMock()
+	book := api.Book{ID: "7", Title: "Dune", Author: "Frank Herbert", Price: 999, Stock: 3}
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		authHeader      string
+		wantCode        int
+		wantBody        string
+		wantDeprecation bool
+		wantLink        string
+	}{
+		{
+			name:     "v1_book_by_path",
+			method:   http.MethodGet,
+			path:     "/api/v1/books/7",
+			wantCode: http.StatusOK,
+			wantBody: `{"book":{"id":"7","title":"Dune","author":"Frank Herbert","description":"","price":"9.99","stock":3}}`,
+		},
+		{
+			name:     "v1_invalid_book_id",
+			method:   http.MethodGet,
+			path:     "/api/v1/books/abc",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: withRequestID(invalid("/api/v1/books/abc", api.ValidationError{Field: "id", Message: "must be a positive integer"})),
+		},
+		{
+			name:            "legacy_book_by_query",
+			method:          http.MethodGet,
+			path:            "/book/?id=7",
+			wantCode:        http.StatusOK,
+			wantBody:        `{"book":{"id":"7","title":"Dune","author":"Frank Herbert","description":"","price":"9.99","stock":3}}`,
+			wantDeprecation: true,
+			wantLink:        `</api/v1/books/7>; rel="successor-version"`,
+		},
+		{
+			name:     "v1_order_history_requires_auth",
+			method:   http.MethodGet,
+			path:     "/api/v1/users/me/orders",
+			wantCode: http.StatusUnauthorized,
+			wantBody: withRequestID(problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/api/v1/users/me/orders")),
+		},
+		{
+			name:       "v1_empty_order_history",
+			method:     http.MethodGet,
+			path:       "/api/v1/users/me/orders",
+			authHeader: "Bearer valid-token",
+			wantCode:   http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:     "v1_user_lookup_requires_auth",
+			method:   http.MethodGet,
+			path:     "/api/v1/users?email=a@example.com",
+			wantCode: http.StatusUnauthorized,
+			wantBody: withRequestID(problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/api/v1/users")),
+		},
+		{
+			name:            "legacy_order_history",
+			method:          http.MethodGet,
+			path:            "/order/history",
+			authHeader:      "Bearer valid-token",
+			wantCode:        http.StatusOK,
+			wantBody:        `{"message":"No Order found for this user"}`,
+			wantDeprecation: true,
+			wantLink:        `</api/v1/users/me/orders>; rel="successor-version"`,
+		},
+		{
+			name:            "legacy_user_lookup_requires_auth",
+			method:          http.MethodGet,
+			path:            "/users/a@example.com",
+			wantCode:        http.StatusUnauthorized,
+			wantBody:        withRequestID(problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/users/a@example.com")),
+			wantDeprecation: true,
+			wantLink:        `</api/v1/users>; rel="successor-version"`,
+		},
+		{
+			name:            "legacy_path_params_are_carried_over",
+			method:          http.MethodPost,
+			path:            "/orders/12/cancel",
+			wantCode:        http.StatusUnauthorized,
+			wantBody:        withRequestID(problem(http.StatusUnauthorized, "authentication_required", "authentication required", "/orders/12/cancel")),
+			wantDeprecation: true,
+			wantLink:        `</api/v1/orders/12/cancel>; rel="successor-version"`,
+		},
+		{
+			name:       "database_stats_require_admin",
+			method:     http.MethodGet,
+			path:       "/api/v1/system/database",
+			authHeader: "Bearer valid-token",
+			wantCode:   http.StatusForbidden,
+			wantBody:   withRequestID(problem(http.StatusForbidden, "insufficient_permissions", "insufficient permissions", "/api/v1/system/database")),
+		},
+		{
+			name:       "database_stats",
+			method:     http.MethodGet,
+			path:       "/api/v1/system/database",
+			authHeader: "Bearer admin-token",
+			wantCode:   http.StatusOK,
+			wantBody:   `{"maxOpenConnections":0,"openConnections":0,"inUse":0,"idle":0,"waitCount":0,"waitDurationMs":0,"maxIdleClosed":0,"maxIdleTimeClosed":0,"maxLifetimeClosed":0}`,
+		},
+		{
+			name:     "liveness_is_unversioned",
+			method:   http.MethodGet,
+			path:     "/healthz",
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ok"}`,
+		},
+		{
+			name:     "readiness_is_unversioned",
+			method:   http.MethodGet,
+			path:     "/readyz",
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ok"}`,
+		},
+		{
+			name:            "legacy_health_is_readiness",
+			method:          http.MethodGet,
+			path:            "/health",
+			wantCode:        http.StatusOK,
+			wantBody:        `{"status":"ok"}`,
+			wantDeprecation: true,
+			wantLink:        `</readyz>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("GetBookByID", mock.Anything, "7").Return(book, nil).Maybe()
+			mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil).Maybe()
+			mockService.On("Authenticate", mock.Anything, "admin-token").Return(api.Principal{UserID: "1", Role: api.RoleAdmin}, nil).Maybe()
+			mockService.On("GetOrderHistory", mock.Anything, "42").Return([]api.Order{}, nil).Maybe()
+			r := api.NewRouter(app, mockService)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(logging.RequestIDHeader, testRequestID)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, testRequestID, w.Header().Get(logging.RequestIDHeader))
+			if tt.wantDeprecation {
+				assert.Equal(t, "@1793491200", w.Header().Get("Deprecation"))
+				assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+				assert.Equal(t, tt.wantLink, w.Header().Get("Link"))
+			} else {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+				assert.Empty(t, w.Header().Get("Sunset"))
+			}
+		})
+	}
+}
+
+func Test_NewRouter_ServesMetrics(t *testing.T) {
+	app := application.NewAppMock()
+	mockService := new(mocks.Service)
+	mockService.On("GetBookByID", mock.Anything, "7").Return(api.Book{ID: "7"}, nil)
+	r := api.NewRouter(app, mockService)
+
+	for _, path := range []string{"/api/v1/books/7", "/metrics"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		if path == "/metrics" {
+			assert.Contains(t, w.Body.String(), `bookstore_http_request_duration_seconds_count{method="GET",route="/api/v1/books/:id",status="200"} 1`)
+		}
+	}
+}
+
+func Test_NewRouter_RecoversFromPanics(t *testing.T) {
+	app := application.NewAppMock()
+	mockService := new(mocks.Service)
+	mockService.On("GetBookByID", mock.Anything, "7").Run(func(mock.Arguments) { panic("boom") })
+	r := api.NewRouter(app, mockService)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/books/7", nil)
+	req.Header.Set(logging.RequestIDHeader, testRequestID)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, withRequestID(problem(http.StatusInternalServerError, "internal", "internal server error", "/api/v1/books/7")), w.Body.String())
+}
+
+func Test_NewRouter_PropagatesTraceContext(t *testing.T) {
+	app := application.NewAppMock()
+	tp, exporter := newRecorder()
+	app.SetTracerProvider(tp)
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("GetBookByID", mock.Anything, "7").Return(api.Book{ID: "7"}, nil).Once()
+	repo := api.NewInstrumentedRepository(mockRepo, app.Metrics(), tp)
+	r := api.NewRouter(app, api.NewTracedService(api.NewService(app, repo), tp))
+
+	for _, path := range []string{"/api/v1/books/7", "/healthz"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3, "probes are not traced")
+	repoSpan, serviceSpan, httpSpan := spans[0], spans[1], spans[2]
+	assert.Equal(t, "Repository.GetBookByID", repoSpan.Name)
+	assert.Equal(t, "Service.GetBookByID", serviceSpan.Name)
+	assert.Equal(t, "/api/v1/books/:id", httpSpan.Name)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	}
+	assert.Equal(t, "00f067aa0ba902b7", httpSpan.Parent.SpanID().String())
+	assert.Equal(t, httpSpan.SpanContext.SpanID(), serviceSpan.Parent.SpanID())
+	assert.Equal(t, serviceSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// The row types below mirror the database columns the repository reads.
+// They are scanned directly and converted to the domain types with their
+// to* methods, so the schema can change without touching the Repository
+// interface or the JSON views.
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// bookColumns lists the columns scanned by bookRow.dest, prefixed with alias
+// when the query needs one.
+func bookColumns(alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+	return alias + "id, " + alias + "title, " + alias + "author, " + alias + "description, " +
+		alias + "price_cents, " + alias + "stock, " + alias + "created_at"
+}
+
+type bookRow struct {
+	ID          int64
+	Title       string
+	Author      string
+	Description string
+	PriceCents  int64
+	Stock       int
+	CreatedAt   time.Time
+}
+
+func (b *bookRow) dest() []any {
+	return []any{&b.ID, &b.Title, &b.Author, &b.Description, &b.PriceCents, &b.Stock, &b.CreatedAt}
+}
+
+func (b bookRow) toBook() Book {
+	return Book{
+		ID:          formatID(b.ID),
+		Title:       b.Title,
+		Author:      b.Author,
+		Description: b.Description,
+		Price:       Money(b.PriceCents),
+		Stock:       b.Stock,
+		CreatedAt:   b.CreatedAt,
+	}
+}
+
+type userRow struct {
+	ID       int64
+	Email    string
+	Password string
+	Role     string
+}
+
+func (u userRow) toUser() User {
+	return User{
+		ID:           formatID(u.ID),
+		Email:        u.Email,
+		PasswordHash: u.Password,
+		Role:         Role(u.Role),
+	}
+}
+
+type orderRow struct {
+	ID            int64
+	UserID        int64
+	Status        string
+	SubtotalCents int64
+	TaxCents      int64
+	TotalCents    int64
+	CreatedAt     time.Time
+}
+
+func (o orderRow) toOrder() Order {
+	return Order{
+		ID:        formatID(o.ID),
+		UserID:    formatID(o.UserID),
+		Status:    OrderStatus(o.Status),
+		Items:     make([]BookOrder, 0),
+		Subtotal:  Money(o.SubtotalCents),
+		Tax:       Money(o.TaxCents),
+		Total:     Money(o.TotalCents),
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+type orderItemRow struct {
+	BookID         int64
+	Quantity       int
+	UnitPriceCents int64
+	Title          string
+}
+
+func (i orderItemRow) toBookOrder() BookOrder {
+	unitPrice := Money(i.UnitPriceCents)
+	return BookOrder{
+		BookID:    formatID(i.BookID),
+		Quantity:  i.Quantity,
+		Title:     i.Title,
+		UnitPrice: unitPrice,
+		LineTotal: unitPrice.Times(i.Quantity),
+	}
+}
+
+type statusHistoryRow struct {
+	OrderID    int64
+	FromStatus sql.NullString
+	ToStatus   string
+	ChangedBy  sql.NullInt64
+	ChangedAt  time.Time
+}
+
+func (h statusHistoryRow) toStatusChange() OrderStatusChange {
+	change := OrderStatusChange{
+		From:      OrderStatus(h.FromStatus.String),
+		To:        OrderStatus(h.ToStatus),
+		ChangedAt: h.ChangedAt,
+	}
+	if h.ChangedBy.Valid {
+		change.ChangedBy = formatID(h.ChangedBy.Int64)
+	}
+	return change
+}
+
+type cartItemRow struct {
+	BookID       int64
+	Title        string
+	Quantity     int
+	PriceCents   int64
+	PriceChanged bool
+	InStock      bool
+}
+
+func (i cartItemRow) toCartItem() CartItem {
+	unitPrice := Money(i.PriceCents)
+	return CartItem{
+		BookID:       formatID(i.BookID),
+		Title:        i.Title,
+		Quantity:     i.Quantity,
+		UnitPrice:    unitPrice,
+		LineTotal:    unitPrice.Times(i.Quantity),
+		PriceChanged: i.PriceChanged,
+		InStock:      i.InStock,
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	maxSearchLength    = 200
+
+	// headlineStart and headlineStop mark matched words in a
+	// BookSearchResult headline.
+	headlineStart = "<b>"
+	headlineStop  = "</b>"
+
+	// matchStart and matchStop delimit matches while a headline is built.
+	// They are private use characters, which HTML escaping leaves alone and
+	// which are removed from the text beforehand so books cannot forge them.
+	matchStart = "\uE000"
+	matchStop  = "\uE001"
+)
+
+// renderHeadline turns text with matches delimited by matchStart and
+// matchStop into the HTML-safe headline format: the text is escaped and
+// only the markers are markup.
+func renderHeadline(marked string) string {
+	return headlineMarkup.Replace(html.EscapeString(marked))
+}
+
+var headlineMarkup = strings.NewReplacer(matchStart, headlineStart, matchStop, headlineStop)
+
+// BookSearch is a full-text catalog query. Every term in Query must match;
+// the last one also matches as a prefix so that partially typed words find
+// results.
+type BookSearch struct {
+	Query string
+	Limit int
+}
+
+// BookSearchResult is a matching book with its relevance and a snippet of
+// its description (or title) with the matched words highlighted. Headline is
+// HTML: the book text in it is escaped and the matches are wrapped in <b>
+// tags, so clients may render it as markup.
+type BookSearchResult struct {
+	Book     Book
+	Rank     float64
+	Headline string
+}
+
+// searchTerms splits a query into lower-case words, dropping punctuation and
+// anything else that could be read as tsquery syntax.
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery builds a to_tsquery expression requiring every term, with
+// prefix matching on the last. It returns "" when q has no words.
+func prefixTSQuery(q string) string {
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += ":*"
+	return strings.Join(terms, " & ")
+}
+
+func validateSearch(search *BookSearch) error {
+	search.Query = strings.TrimSpace(search.Query)
+	switch {
+	case search.Query == "":
+		return ValidationError{"q", "is required"}
+	case len(search.Query) > maxSearchLength:
+		return ValidationError{"q", fmt.Sprintf("must be at most %d characters", maxSearchLength)}
+	case search.Limit == 0:
+		search.Limit = DefaultSearchLimit
+	case search.Limit < 0 || search.Limit > MaxSearchLimit:
+		return ValidationError{"limit", fmt.Sprintf("must be between 1 and %d", MaxSearchLimit)}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field weights used by BookIndex, matching the ts_rank defaults for the A, B
+// and C labels given to title, author and description.
+const (
+	titleWeight       = 1.0
+	authorWeight      = 0.4
+	descriptionWeight = 0.2
+)
+
+// BookIndex is an in-memory implementation of Repository.SearchBooks for
+// tests and tools that run without Postgres. It matches whole lower-cased
+// words rather than stemmed lexemes, so it finds a subset of what the
+// database would for the same query, in the same order of relevance.
+type BookIndex struct {
+	books []indexedBook
+}
+
+type indexedBook struct {
+	book        Book
+	title       []string
+	author      []string
+	description []string
+}
+
+func NewBookIndex(books []Book) *BookIndex {
+	idx := &BookIndex{books: make([]indexedBook, 0, len(books))}
+	for _, b := range books {
+		idx.books = append(idx.books, indexedBook{
+			book:        b,
+			title:       searchTerms(b.Title),
+			author:      searchTerms(b.Author),
+			description: searchTerms(b.Description),
+		})
+	}
+	return idx
+}
+
+func (idx *BookIndex) SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error) {
+	results := make([]BookSearchResult, 0)
+	terms := searchTerms(search.Query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+	for _, b := range idx.books {
+		rank, ok := b.rank(terms)
+		if !ok {
+			continue
+		}
+		text := b.book.Description
+		if text == "" {
+			text = b.book.Title
+		}
+		results = append(results, BookSearchResult{
+			Book:     b.book,
+			Rank:     rank,
+			Headline: highlight(text, terms),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return idLess(results[i].Book.ID, results[j].Book.ID)
+	})
+	if search.Limit > 0 && len(results) > search.Limit {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+// rank sums the weighted occurrences of every term, reporting false unless
+// all of them occur somewhere in the book.
+func (b indexedBook) rank(terms []string) (float64, bool) {
+	var total float64
+	for i, term := range terms {
+		prefix := i == len(terms)-1
+		score := titleWeight*float64(countMatches(b.title, term, prefix)) +
+			authorWeight*float64(countMatches(b.author, term, prefix)) +
+			descriptionWeight*float64(countMatches(b.description, term, prefix))
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func countMatches(words []string, term string, prefix bool) int {
+	n := 0
+	for _, w := range words {
+		if w == term || (prefix && strings.HasPrefix(w, term)) {
+			n++
+		}
+	}
+	return n
+}
+
+// highlight returns the headline of text with the words that match terms
+// marked.
+func highlight(text string, terms []string) string {
+	var sb strings.Builder
+	word := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	runes := []rune(stripMatchMarkers.Replace(text))
+	for i := 0; i < len(runes); {
+		if !word(runes[i]) {
+			sb.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && word(runes[j]) {
+			j++
+		}
+		w := string(runes[i:j])
+		lower := strings.ToLower(w)
+		matched := false
+		for k, term := range terms {
+			if lower == term || (k == len(terms)-1 && strings.HasPrefix(lower, term)) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			sb.WriteString(matchStart + w + matchStop)
+		} else {
+			sb.WriteString(w)
+		}
+		i = j
+	}
+	return renderHeadline(sb.String())
+}
+
+var stripMatchMarkers = strings.NewReplacer(matchStart, "", matchStop, "")
+
+// idLess orders numeric IDs numerically, like the id column does.
+func idLess(a, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return x < y
+}
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BookIndex_SearchBooks(t *testing.T) {
+	index := api.NewBookIndex([]api.Book{
+		{ID: "1", Title: "Dune", Author: "Frank Herbert", Description: "Spice, sand and the desert planet Arrakis."},
+		{ID: "2", Title: "Dune Messiah", Author: "Frank Herbert", Description: "The sequel to Dune."},
+		{ID: "3", Title: "The Left Hand of Darkness", Author: "Ursula K. Le Guin", Description: "An envoy on the winter planet Gethen."},
+		{ID: "10", Title: "Children of Dune", Author: "Frank Herbert"},
+		{ID: "11", Title: "Mischief", Author: "Mallory", Description: `<script>alert("x")</script> exploit & more` + "\uE000"},
+	})
+
+	tests := []struct {
+		name          string
+		search        api.BookSearch
+		wantIDs       []string
+		wantHeadlines []string
+	}{
+		{
+			name:    "title matches outrank description matches",
+			search:  api.BookSearch{Query: "dune"},
+			wantIDs: []string{"2", "1", "10"},
+		},
+		{
+			name:          "every term must match",
+			search:        api.BookSearch{Query: "herbert desert"},
+			wantIDs:       []string{"1"},
+			wantHeadlines: []string{"Spice, sand and the <b>desert</b> planet Arrakis."},
+		},
+		{
+			name:          "last term matches as a prefix",
+			search:        api.BookSearch{Query: "winter pla"},
+			wantIDs:       []string{"3"},
+			wantHeadlines: []string{"An envoy on the <b>winter</b> <b>planet</b> Gethen."},
+		},
+		{
+			name:          "headline text is escaped",
+			search:        api.BookSearch{Query: "exploit"},
+			wantIDs:       []string{"11"},
+			wantHeadlines: []string{"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <b>exploit</b> &amp; more"},
+		},
+		{
+			name:          "headline falls back to the title",
+			search:        api.BookSearch{Query: "children"},
+			wantIDs:       []string{"10"},
+			wantHeadlines: []string{"<b>Children</b> of Dune"},
+		},
+		{
+			name:    "ties are ordered by numeric id",
+			search:  api.BookSearch{Query: "frank"},
+			wantIDs: []string{"1", "2", "10"},
+		},
+		{
+			name:    "limit",
+			search:  api.BookSearch{Query: "frank", Limit: 2},
+			wantIDs: []string{"1", "2"},
+		},
+		{
+			name:    "punctuation only",
+			search:  api.BookSearch{Query: "&|!"},
+			wantIDs: []string{},
+		},
+		{
+			name:    "no match",
+			search:  api.BookSearch{Query: "dragons"},
+			wantIDs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := index.SearchBooks(context.Background(), tt.search)
+			assert.NoError(t, err)
+			ids := make([]string, 0)
+			for _, r := range results {
+				ids = append(ids, r.Book.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			if tt.wantHeadlines != nil {
+				headlines := make([]string, 0)
+				for _, r := range results {
+					headlines = append(headlines, r.Headline)
+				}
+				assert.Equal(t, tt.wantHeadlines, headlines)
+			}
+		})
+	}
+}
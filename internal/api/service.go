@@ -2,44 +2,224 @@ package api
 
 import (
 	"bookstore/internal/application"
+	"bookstore/internal/application/config"
+	"bookstore/internal/auth"
 	"context"
+	"fmt"
+	"log/slog"
 )
 
 type Service interface {
-	GetAllBooks(ctx context.Context) ([]Book, error)
+	GetAllBooks(ctx context.Context, query BookQuery) (BookPage, error)
+	SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error)
 	CreateAccount(ctx context.Context, email, password string) error
-	PlaceOrder(ctx context.Context, email string, books []BookOrder) error
+	PlaceOrder(ctx context.Context, email string, books []BookOrder) (Order, error)
 	GetOrderHistory(ctx context.Context, email string) ([]Order, error)
 	GetUserIDByEmail(ctx context.Context, email string) (string, error)
 	GetBookByID(ctx context.Context, bookID string) (Book, error)
+	GetBooksByIDs(ctx context.Context, bookIDs []string) ([]Book, error)
+	GetUser(ctx context.Context, userID string) (User, error)
+	VerifyCredentials(ctx context.Context, email, password string) (User, error)
+	CreateSession(ctx context.Context, email, password string) (Session, error)
+	Authenticate(ctx context.Context, token string) (Principal, error)
+	CreateBook(ctx context.Context, book Book) (Book, error)
+	UpdateBook(ctx context.Context, book Book) (Book, error)
+	PatchBook(ctx context.Context, bookID string, patch BookPatch) (Book, error)
+	DeleteBook(ctx context.Context, bookID string) error
+	GetCart(ctx context.Context, userID string) (Cart, error)
+	ReplaceCart(ctx context.Context, userID string, items []BookOrder) (Cart, error)
+	AddToCart(ctx context.Context, userID string, item BookOrder) (Cart, error)
+	RemoveFromCart(ctx context.Context, userID, bookID string) (Cart, error)
+	Checkout(ctx context.Context, userID string) (Order, error)
+	CancelOrder(ctx context.Context, userID, orderID string) (Order, error)
+	TransitionOrder(ctx context.Context, actorID, orderID string, status OrderStatus) (Order, error)
 }
 
 type service struct {
-	app  *application.Application
-	repo Repository
+	app    *application.Application
+	repo   Repository
+	tokens *auth.TokenManager
 }
 
 func NewService(app *application.Application, repo Repository) Service {
 	return &service{
-		app:  app,
-		repo: repo,
+		app:    app,
+		repo:   repo,
+		tokens: newTokenManager(app.Config(), app.Logger()),
 	}
 }
 
-func (s service) GetAllBooks(ctx context.Context) ([]Book, error) {
+func newTokenManager(cfg *config.Config, logger *slog.Logger) *auth.TokenManager {
+	secret, ttl := []byte(nil), config.DefaultSessionTTL
+	if cfg != nil {
+		secret = []byte(cfg.SessionSecret)
+		if cfg.SessionTTL > 0 {
+			ttl = cfg.SessionTTL
+		}
+	}
+	if len(secret) == 0 {
+		var err error
+		if secret, err = auth.RandomSecret(); err != nil {
+			panic(err)
+		}
+		logger.Warn("SESSION_SECRET is not set, using a random secret; sessions will not survive a restart")
+	}
+	return auth.NewTokenManager(secret, ttl)
+}
 
-	return s.repo.GetAllBooks(ctx)
+func (s service) GetAllBooks(ctx context.Context, query BookQuery) (BookPage, error) {
+	if err := query.Normalize(); err != nil {
+		return BookPage{}, err
+	}
+	return s.repo.GetAllBooks(ctx, query)
+}
+
+func (s service) SearchBooks(ctx context.Context, search BookSearch) ([]BookSearchResult, error) {
+	if err := validateSearch(&search); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchBooks(ctx, search)
 }
 
 func (s service) CreateAccount(ctx context.Context, email, password string) error {
+	hash, err := hashPassword(password, s.bcryptCost())
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateAccount(ctx, email, hash); err != nil {
+		return err
+	}
+	s.app.Metrics().AccountsCreated.Inc()
+	return nil
+}
+
+// VerifyCredentials checks password against the stored hash for email. When
+// the hash was produced with different parameters than are configured now it
+// is replaced, so cost changes roll out as users log in.
+func (s service) VerifyCredentials(ctx context.Context, email, password string) (User, error) {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+	if user.ID == "" {
+		checkNoPassword(password, s.bcryptCost())
+		return User{}, ErrInvalidCredentials
+	}
+	if !checkPassword(user.PasswordHash, password) {
+		return User{}, ErrInvalidCredentials
+	}
+
+	cost := s.bcryptCost()
+	if needsRehash(user.PasswordHash, cost) {
+		hash, err := hashPassword(password, cost)
+		if err == nil {
+			err = s.repo.UpdatePassword(ctx, user.ID, hash)
+		}
+		if err != nil {
+			// The login itself succeeded; the upgrade is retried next time.
+			s.app.Logger().WarnContext(ctx, "Error rehashing password", "user_id", user.ID, "error", err)
+		}
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// CreateSession verifies the credentials and issues a signed session token.
+func (s service) CreateSession(ctx context.Context, email, password string) (Session, error) {
+	user, err := s.VerifyCredentials(ctx, email, password)
+	if err != nil {
+		return Session{}, err
+	}
+	token, claims, err := s.tokens.Issue(user.ID)
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{
+		Token:     token,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+// Authenticate resolves a session token to the user it was issued to. The
+// role is read from the database rather than the token so that role changes
+// and deleted accounts take effect immediately.
+func (s service) Authenticate(ctx context.Context, token string) (Principal, error) {
+	claims, err := s.tokens.Verify(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return Principal{}, err
+	}
+	if user.ID == "" {
+		return Principal{}, auth.ErrInvalidToken
+	}
+	return Principal{UserID: user.ID, Role: user.Role}, nil
+}
 
-	return s.repo.CreateAccount(ctx, email, password)
+func (s service) CreateBook(ctx context.Context, book Book) (Book, error) {
+	if err := validateBook(book); err != nil {
+		return Book{}, err
+	}
+	return s.repo.CreateBook(ctx, book)
+}
+
+func (s service) UpdateBook(ctx context.Context, book Book) (Book, error) {
+	if err := validateID("id", book.ID); err != nil {
+		return Book{}, err
+	}
+	if err := validateBook(book); err != nil {
+		return Book{}, err
+	}
+	return s.repo.UpdateBook(ctx, book)
+}
+
+// PatchBook changes only the fields patch sets, so that it cannot undo
+// concurrent changes to the others, such as stock taken by an order.
+func (s service) PatchBook(ctx context.Context, bookID string, patch BookPatch) (Book, error) {
+	if err := validateID("id", bookID); err != nil {
+		return Book{}, err
+	}
+	if err := validateBookPatch(patch); err != nil {
+		return Book{}, err
+	}
+	return s.repo.PatchBook(ctx, bookID, patch)
 }
 
-func (s service) PlaceOrder(ctx context.Context, email string, books []BookOrder) error {
+func (s service) DeleteBook(ctx context.Context, bookID string) error {
+	if err := validateID("id", bookID); err != nil {
+		return err
+	}
+	return s.repo.DeleteBook(ctx, bookID)
+}
 
-	return s.repo.PlaceOrder(ctx, email, books)
-	//return nil
+func (s service) bcryptCost() int {
+	if cfg := s.app.Config(); cfg != nil && cfg.BcryptCost != 0 {
+		return cfg.BcryptCost
+	}
+	return config.DefaultBcryptCost
+}
+
+func (s service) PlaceOrder(ctx context.Context, email string, books []BookOrder) (Order, error) {
+	if err := validateOrderItems(books); err != nil {
+		return Order{}, err
+	}
+	order, err := s.repo.PlaceOrder(ctx, email, books)
+	if err != nil {
+		return Order{}, err
+	}
+	s.recordOrder(order)
+	return order, nil
+}
+
+// recordOrder counts a placed order in the business metrics.
+func (s service) recordOrder(order Order) {
+	m := s.app.Metrics()
+	m.OrdersPlaced.Inc()
+	m.OrderValuePlacedCents.Add(float64(order.Total))
 }
 
 func (s service) GetOrderHistory(ctx context.Context, email string) ([]Order, error) {
@@ -52,5 +232,36 @@ func (s service) GetUserIDByEmail(ctx context.Context, email string) (string, er
 }
 
 func (s service) GetBookByID(ctx context.Context, bookID string) (Book, error) {
+	if err := validateID("id", bookID); err != nil {
+		return Book{}, err
+	}
 	return s.repo.GetBookByID(ctx, bookID)
 }
+
+// GetBooksByIDs returns the books that exist among bookIDs in a single
+// repository call. IDs that are not positive integers are rejected up front
+// because Postgres would fail the whole batch on them.
+func (s service) GetBooksByIDs(ctx context.Context, bookIDs []string) ([]Book, error) {
+	for i, id := range bookIDs {
+		if err := validateID(fmt.Sprintf("ids[%d]", i), id); err != nil {
+			return nil, err
+		}
+	}
+	if len(bookIDs) == 0 {
+		return []Book{}, nil
+	}
+	return s.repo.GetBooksByIDs(ctx, bookIDs)
+}
+
+// GetUser returns the account for userID without its password hash.
+func (s service) GetUser(ctx context.Context, userID string) (User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return User{}, err
+	}
+	if user.ID == "" {
+		return User{}, ErrUserNotFound
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
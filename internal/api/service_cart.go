@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+func (s service) GetCart(ctx context.Context, userID string) (Cart, error) {
+	return s.repo.GetCart(ctx, userID)
+}
+
+// ReplaceCart overwrites the cart. Lines for the same book are merged.
+func (s service) ReplaceCart(ctx context.Context, userID string, items []BookOrder) (Cart, error) {
+	if len(items) > 0 {
+		if err := validateOrderItems(items); err != nil {
+			return Cart{}, err
+		}
+	}
+	if err := s.repo.ReplaceCart(ctx, userID, mergeBookOrders(items)); err != nil {
+		return Cart{}, err
+	}
+	return s.repo.GetCart(ctx, userID)
+}
+
+func (s service) AddToCart(ctx context.Context, userID string, item BookOrder) (Cart, error) {
+	if err := validateOrderItems([]BookOrder{item}); err != nil {
+		return Cart{}, err
+	}
+	if err := s.repo.AddCartItem(ctx, userID, item.BookID, item.Quantity); err != nil {
+		return Cart{}, err
+	}
+	return s.repo.GetCart(ctx, userID)
+}
+
+func (s service) RemoveFromCart(ctx context.Context, userID, bookID string) (Cart, error) {
+	if err := validateID("bookId", bookID); err != nil {
+		return Cart{}, err
+	}
+	if err := s.repo.RemoveCartItems(ctx, userID, []string{bookID}); err != nil {
+		return Cart{}, err
+	}
+	return s.repo.GetCart(ctx, userID)
+}
+
+// Checkout turns the cart into an order in a single repository transaction
+// that also takes the ordered quantities out of the cart. If any catalog
+// price moved since the user last saw it the checkout is refused and the
+// cart is repriced, so a second attempt after reviewing the cart succeeds.
+func (s service) Checkout(ctx context.Context, userID string) (Order, error) {
+	order, err := s.repo.CheckoutCart(ctx, userID)
+	var changed *PriceChangedError
+	if errors.As(err, &changed) {
+		if err := s.repo.RefreshCartPrices(ctx, userID); err != nil {
+			return Order{}, err
+		}
+		return Order{}, changed
+	}
+	if err != nil {
+		return Order{}, err
+	}
+	s.recordOrder(order)
+	return order, nil
+}
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_Checkout(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	order := api.Order{ID: "9", UserID: "42", Subtotal: 2498, Total: 2498}
+
+	tests := []struct {
+		name          string
+		checkoutOrder api.Order
+		checkoutErr   error
+		wantRefresh   bool
+		expectedOrder api.Order
+		expectedErr   error
+	}{
+		{
+			name:          "Cart becomes an order",
+			checkoutOrder: order,
+			expectedOrder: order,
+		},
+		{
+			name:        "Empty cart",
+			checkoutErr: api.ErrCartEmpty,
+			expectedErr: api.ErrCartEmpty,
+		},
+		{
+			name:        "Price changed reprices the cart",
+			checkoutErr: &api.PriceChangedError{BookIDs: []string{"2"}},
+			wantRefresh: true,
+			expectedErr: &api.PriceChangedError{BookIDs: []string{"2"}},
+		},
+		{
+			name:        "Out of stock keeps the cart",
+			checkoutErr: &api.InsufficientStockError{BookIDs: []string{"1"}},
+			expectedErr: &api.InsufficientStockError{BookIDs: []string{"1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("CheckoutCart", c, "42").Return(tt.checkoutOrder, tt.checkoutErr).Once()
+			if tt.wantRefresh {
+				mockRepo.On("RefreshCartPrices", c, "42").Return(nil).Once()
+			}
+
+			svc := api.NewService(app, mockRepo)
+			got, err := svc.Checkout(c, "42")
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedOrder, got)
+		})
+	}
+}
+
+func Test_Service_ReplaceCart(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	mockRepo := mocks.NewRepository(t)
+	merged := []api.BookOrder{{BookID: "1", Quantity: 3}, {BookID: "2", Quantity: 1}}
+	mockRepo.On("ReplaceCart", c, "42", merged).Return(nil).Once()
+	mockRepo.On("GetCart", c, "42").Return(api.Cart{}, nil).Once()
+
+	svc := api.NewService(app, mockRepo)
+	_, err := svc.ReplaceCart(c, "42", []api.BookOrder{
+		{BookID: "1", Quantity: 1},
+		{BookID: "2", Quantity: 1},
+		{BookID: "1", Quantity: 2},
+	})
+	assert.NoError(t, err)
+
+	_, err = svc.ReplaceCart(c, "42", []api.BookOrder{{BookID: "1", Quantity: 0}})
+	assert.Equal(t, api.ValidationError{Field: "quantity", Message: "must be positive"}, err)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"slices"
+)
+
+// CancelOrder cancels one of the caller's own orders and returns its copies
+// to stock. Orders belonging to someone else are reported as not found.
+func (s service) CancelOrder(ctx context.Context, userID, orderID string) (Order, error) {
+	if err := validateID("id", orderID); err != nil {
+		return Order{}, err
+	}
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.UserID != userID {
+		return Order{}, ErrOrderNotFound
+	}
+	if !slices.Contains(customerCancellable, order.Status) {
+		return Order{}, &InvalidTransitionError{From: order.Status, To: OrderCancelled}
+	}
+	return s.transition(ctx, order, OrderCancelled, userID)
+}
+
+// TransitionOrder moves any order to status on behalf of staff.
+func (s service) TransitionOrder(ctx context.Context, actorID, orderID string, status OrderStatus) (Order, error) {
+	if err := validateID("id", orderID); err != nil {
+		return Order{}, err
+	}
+	if !status.Valid() {
+		return Order{}, ValidationError{"status", "is not a known order status"}
+	}
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != nil {
+		return Order{}, err
+	}
+	return s.transition(ctx, order, status, actorID)
+}
+
+func (s service) transition(ctx context.Context, order Order, to OrderStatus, actorID string) (Order, error) {
+	if !CanTransition(order.Status, to) {
+		return Order{}, &InvalidTransitionError{From: order.Status, To: to}
+	}
+	err := s.repo.UpdateOrderStatus(ctx, StatusUpdate{
+		OrderID: order.ID,
+		From:    order.Status,
+		To:      to,
+		ActorID: actorID,
+		Restock: Restocks(order.Status, to),
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	return s.repo.GetOrder(ctx, order.ID)
+}
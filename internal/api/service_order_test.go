@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_CancelOrder(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	tests := []struct {
+		name        string
+		stored      api.Order
+		wantUpdate  *api.StatusUpdate
+		expectedErr error
+	}{
+		{
+			name:       "Pending order is cancelled and restocked",
+			stored:     api.Order{ID: "9", UserID: "42", Status: api.OrderPending},
+			wantUpdate: &api.StatusUpdate{OrderID: "9", From: api.OrderPending, To: api.OrderCancelled, ActorID: "42", Restock: true},
+		},
+		{
+			name:       "Paid order can still be cancelled",
+			stored:     api.Order{ID: "9", UserID: "42", Status: api.OrderPaid},
+			wantUpdate: &api.StatusUpdate{OrderID: "9", From: api.OrderPaid, To: api.OrderCancelled, ActorID: "42", Restock: true},
+		},
+		{
+			name:        "Shipped order cannot be cancelled by the customer",
+			stored:      api.Order{ID: "9", UserID: "42", Status: api.OrderShipped},
+			expectedErr: &api.InvalidTransitionError{From: api.OrderShipped, To: api.OrderCancelled},
+		},
+		{
+			name:        "Someone else's order is not found",
+			stored:      api.Order{ID: "9", UserID: "7", Status: api.OrderPending},
+			expectedErr: api.ErrOrderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("GetOrder", c, "9").Return(tt.stored, nil).Once()
+			if tt.wantUpdate != nil {
+				mockRepo.On("UpdateOrderStatus", c, *tt.wantUpdate).Return(nil).Once()
+				cancelled := tt.stored
+				cancelled.Status = api.OrderCancelled
+				mockRepo.On("GetOrder", c, "9").Return(cancelled, nil).Once()
+			}
+			svc := api.NewService(app, mockRepo)
+			order, err := svc.CancelOrder(c, "42", "9")
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, api.OrderCancelled, order.Status)
+			}
+		})
+	}
+}
+
+func Test_Service_TransitionOrder(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	tests := []struct {
+		name        string
+		from        api.OrderStatus
+		to          api.OrderStatus
+		restock     bool
+		updateErr   error
+		expectedErr error
+	}{
+		{name: "Ship a paid order", from: api.OrderPaid, to: api.OrderShipped},
+		{name: "Deliver a shipped order", from: api.OrderShipped, to: api.OrderDelivered},
+		{name: "Refund a delivered order without restocking", from: api.OrderDelivered, to: api.OrderRefunded},
+		{name: "Refund a paid order and restock it", from: api.OrderPaid, to: api.OrderRefunded, restock: true},
+		{name: "Cancel a paid order and restock it", from: api.OrderPaid, to: api.OrderCancelled, restock: true},
+		{
+			name:        "Cannot skip payment",
+			from:        api.OrderPending,
+			to:          api.OrderShipped,
+			expectedErr: &api.InvalidTransitionError{From: api.OrderPending, To: api.OrderShipped},
+		},
+		{
+			name:        "Cancelled is terminal",
+			from:        api.OrderCancelled,
+			to:          api.OrderPaid,
+			expectedErr: &api.InvalidTransitionError{From: api.OrderCancelled, To: api.OrderPaid},
+		},
+		{
+			name:        "Concurrent change",
+			from:        api.OrderPaid,
+			to:          api.OrderShipped,
+			updateErr:   api.ErrOrderStatusConflict,
+			expectedErr: api.ErrOrderStatusConflict,
+		},
+		{
+			name:        "Unknown status",
+			from:        api.OrderPaid,
+			to:          "lost",
+			expectedErr: api.ValidationError{Field: "status", Message: "is not a known order status"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.Repository)
+			mockRepo.On("GetOrder", c, "9").Return(api.Order{ID: "9", UserID: "42", Status: tt.from}, nil).Once()
+			mockRepo.On("UpdateOrderStatus", c, api.StatusUpdate{OrderID: "9", From: tt.from, To: tt.to, ActorID: "1", Restock: tt.restock}).Return(tt.updateErr).Once()
+			mockRepo.On("GetOrder", c, "9").Return(api.Order{ID: "9", UserID: "42", Status: tt.to}, nil).Once()
+			svc := api.NewService(app, mockRepo)
+			order, err := svc.TransitionOrder(c, "1", "9", tt.to)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.to, order.Status)
+			}
+		})
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func Test_Service_CreateAccount(t *testing.T) {
@@ -41,7 +45,10 @@ func Test_Service_CreateAccount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(mocks.Repository)
-			mockRepo.On("CreateAccount", c, tt.email, tt.password).Return(tt.repoErr).Once()
+			matchesPassword := mock.MatchedBy(func(hash string) bool {
+				return hash != tt.password && bcrypt.CompareHashAndPassword([]byte(hash), []byte(tt.password)) == nil
+			})
+			mockRepo.On("CreateAccount", c, tt.email, matchesPassword).Return(tt.repoErr).Once()
 			s := api.NewService(app, mockRepo)
 			err := s.CreateAccount(c, tt.email, tt.password)
 			assert.Equal(t, tt.expectedErr, err)
@@ -49,47 +56,194 @@ func Test_Service_CreateAccount(t *testing.T) {
 	}
 }
 
+func Test_Service_VerifyCredentials(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	weakHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	tests := []struct {
+		name        string
+		password    string
+		repoUser    api.User
+		repoErr     error
+		wantRehash  bool
+		expectedErr error
+	}{
+		{
+			name:     "Valid credentials",
+			password: "password123",
+			repoUser: api.User{ID: "1", Email: "test@example.com", PasswordHash: string(currentHash)},
+		},
+		{
+			name:        "Wrong password",
+			password:    "wrong",
+			repoUser:    api.User{ID: "1", Email: "test@example.com", PasswordHash: string(currentHash)},
+			expectedErr: api.ErrInvalidCredentials,
+		},
+		{
+			name:        "Unknown email",
+			password:    "password123",
+			repoUser:    api.User{},
+			expectedErr: api.ErrInvalidCredentials,
+		},
+		{
+			name:       "Outdated cost is rehashed",
+			password:   "password123",
+			repoUser:   api.User{ID: "1", Email: "test@example.com", PasswordHash: string(weakHash)},
+			wantRehash: true,
+		},
+		{
+			name:       "Legacy plaintext is rehashed",
+			password:   "password123",
+			repoUser:   api.User{ID: "1", Email: "test@example.com", PasswordHash: "password123"},
+			wantRehash: true,
+		},
+		{
+			name:        "Repository error",
+			password:    "password123",
+			repoErr:     errors.New("repository error"),
+			expectedErr: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepository(t)
+			mockRepo.On("GetUserByEmail", c, "test@example.com").Return(tt.repoUser, tt.repoErr).Once()
+			if tt.wantRehash {
+				mockRepo.On("UpdatePassword", c, tt.repoUser.ID, mock.MatchedBy(func(hash string) bool {
+					cost, err := bcrypt.Cost([]byte(hash))
+					return err == nil && cost == bcrypt.DefaultCost
+				})).Return(nil).Once()
+			}
+			svc := api.NewService(app, mockRepo)
+			user, err := svc.VerifyCredentials(c, "test@example.com", tt.password)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.repoUser.ID, user.ID)
+				assert.Empty(t, user.PasswordHash)
+			}
+		})
+	}
+}
+
+// Test_Service_VerifyCredentials_UnknownEmailTiming checks that an unknown
+// email is not rejected faster than a wrong password, which would tell
+// callers which accounts exist.
+func Test_Service_VerifyCredentials_UnknownEmailTiming(t *testing.T) {
+	c := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("GetUserByEmail", c, "ann@example.com").Return(api.User{ID: "1", PasswordHash: string(hash)}, nil)
+	mockRepo.On("GetUserByEmail", c, "nobody@example.com").Return(api.User{}, nil)
+	svc := api.NewService(application.NewAppMock(), mockRepo)
+
+	elapsed := func(email string) time.Duration {
+		start := time.Now()
+		_, err := svc.VerifyCredentials(c, email, "wrong-password1")
+		require.ErrorIs(t, err, api.ErrInvalidCredentials)
+		return time.Since(start)
+	}
+	elapsed("nobody@example.com") // computes the dummy hash
+	unknown, wrong := elapsed("nobody@example.com"), elapsed("ann@example.com")
+
+	assert.Greater(t, unknown, wrong/2, "unknown email %v, wrong password %v", unknown, wrong)
+}
+
 func Test_Service_GetAllBooks(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
 
-	mockBooks := []api.Book{
-		{ID: "1", Title: "Book 1", Author: "Author 1"},
-		{ID: "2", Title: "Book 2", Author: "Author 2"},
+	mockPage := api.BookPage{
+		Items: []api.Book{
+			{ID: "1", Title: "Book 1", Author: "Author 1"},
+			{ID: "2", Title: "Book 2", Author: "Author 2"},
+		},
+		Total: 2,
 	}
+	cursor := api.BookCursor{Sort: api.SortByPrice, Value: "999", ID: 2}
+	low, high := api.Money(1000), api.Money(500)
 
 	tests := []struct {
-		name          string
-		repoBooks     []api.Book
-		repoErr       error
-		expectedBooks []api.Book
-		expectedErr   error
+		name         string
+		query        api.BookQuery
+		repoQuery    *api.BookQuery
+		repoPage     api.BookPage
+		repoErr      error
+		expectedPage api.BookPage
+		expectedErr  error
 	}{
 		{
-			name:          "Successful retrieval of books",
-			repoBooks:     mockBooks,
-			repoErr:       nil,
-			expectedBooks: mockBooks,
-			expectedErr:   nil,
+			name:         "Defaults are applied",
+			query:        api.BookQuery{},
+			repoQuery:    &api.BookQuery{Sort: api.SortByTitle, Limit: api.DefaultBookPageSize},
+			repoPage:     mockPage,
+			expectedPage: mockPage,
+		},
+		{
+			name:         "Cursor is decoded",
+			query:        api.BookQuery{Sort: api.SortByPrice, Cursor: cursor.Encode(), Limit: 2},
+			repoQuery:    &api.BookQuery{Sort: api.SortByPrice, Cursor: cursor.Encode(), Limit: 2, After: &cursor},
+			repoPage:     mockPage,
+			expectedPage: mockPage,
+		},
+		{
+			name:        "Cursor from another sort",
+			query:       api.BookQuery{Sort: api.SortByTitle, Cursor: cursor.Encode()},
+			expectedErr: api.ValidationError{Field: "cursor", Message: "does not match the requested sort"},
 		},
 		{
-			name:          "Repository error",
-			repoBooks:     nil,
-			repoErr:       errors.New("repository error"),
-			expectedBooks: nil,
-			expectedErr:   errors.New("repository error"),
+			name:        "Garbage cursor",
+			query:       api.BookQuery{Cursor: "%%%"},
+			expectedErr: api.ValidationError{Field: "cursor", Message: "is invalid"},
+		},
+		{
+			name:        "Tampered cursor value",
+			query:       api.BookQuery{Sort: api.SortByPrice, Cursor: api.BookCursor{Sort: api.SortByPrice, Value: "abc", ID: 2}.Encode()},
+			expectedErr: api.ValidationError{Field: "cursor", Message: "is invalid"},
+		},
+		{
+			name:        "Tampered creation time",
+			query:       api.BookQuery{Sort: api.SortByCreated, Cursor: api.BookCursor{Sort: api.SortByCreated, Value: "yesterday", ID: 2}.Encode()},
+			expectedErr: api.ValidationError{Field: "cursor", Message: "is invalid"},
+		},
+		{
+			name:        "Title cursor with NUL",
+			query:       api.BookQuery{Cursor: api.BookCursor{Sort: api.SortByTitle, Value: "a\x00b", ID: 2}.Encode()},
+			expectedErr: api.ValidationError{Field: "cursor", Message: "is invalid"},
+		},
+		{
+			name:        "Limit too large",
+			query:       api.BookQuery{Limit: api.MaxBookPageSize + 1},
+			expectedErr: api.ValidationError{Field: "limit", Message: "must be between 1 and 100"},
+		},
+		{
+			name:        "Inverted price range",
+			query:       api.BookQuery{MinPrice: &low, MaxPrice: &high},
+			expectedErr: api.ValidationError{Field: "max_price", Message: "must not be less than min_price"},
+		},
+		{
+			name:        "Repository error",
+			query:       api.BookQuery{},
+			repoQuery:   &api.BookQuery{Sort: api.SortByTitle, Limit: api.DefaultBookPageSize},
+			repoErr:     errors.New("repository error"),
+			expectedErr: errors.New("repository error"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := new(mocks.Repository)
-			mockRepo.On("GetAllBooks", c, mock.Anything).Return(tt.repoBooks, tt.repoErr).Once()
+			mockRepo := mocks.NewRepository(t)
+			if tt.repoQuery != nil {
+				mockRepo.On("GetAllBooks", c, *tt.repoQuery).Return(tt.repoPage, tt.repoErr).Once()
+			}
 			s := api.NewService(app, mockRepo)
 
-			books, err := s.GetAllBooks(context.Background())
+			page, err := s.GetAllBooks(context.Background(), tt.query)
 
-			assert.Equal(t, tt.expectedBooks, books)
+			assert.Equal(t, tt.expectedPage, page)
 			assert.Equal(t, tt.expectedErr, err)
 		})
 	}
@@ -174,19 +328,68 @@ func Test_Service_PlaceOrder(t *testing.T) {
 			repoErr:     errors.New("repository error"),
 			expectedErr: errors.New("repository error"),
 		},
+		{
+			name:        "Non-positive quantity",
+			email:       mockEmail,
+			books:       []api.BookOrder{{BookID: "1", Quantity: 0}},
+			expectedErr: api.ValidationError{Field: "quantity", Message: "must be positive"},
+		},
+		{
+			name:        "Quantity above the limit",
+			email:       mockEmail,
+			books:       []api.BookOrder{{BookID: "1", Quantity: 101}},
+			expectedErr: api.ValidationError{Field: "quantity", Message: "must be at most 100"},
+		},
+		{
+			name:        "Empty order",
+			email:       mockEmail,
+			books:       nil,
+			expectedErr: api.ValidationError{Field: "items", Message: "must not be empty"},
+		},
+		{
+			name:        "Insufficient stock",
+			email:       mockEmail,
+			books:       mockBooks,
+			repoErr:     &api.InsufficientStockError{BookIDs: []string{"2"}},
+			expectedErr: &api.InsufficientStockError{BookIDs: []string{"2"}},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(mocks.Repository)
-			mockRepo.On("PlaceOrder", c, tt.email, tt.books).Return(tt.repoErr).Once()
+			mockRepo.On("PlaceOrder", c, tt.email, tt.books).Return(api.Order{}, tt.repoErr).Once()
 			svc := api.NewService(app, mockRepo)
-			err := svc.PlaceOrder(context.Background(), tt.email, tt.books)
+			_, err := svc.PlaceOrder(context.Background(), tt.email, tt.books)
 			assert.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
 
+func Test_Service_RecordsBusinessMetrics(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+	books := []api.BookOrder{{BookID: "1", Quantity: 2}}
+	mockRepo := mocks.NewRepository(t)
+	mockRepo.On("CreateAccount", c, "test@example.com", mock.Anything).Return(nil).Once()
+	mockRepo.On("CreateAccount", c, "taken@example.com", mock.Anything).Return(api.ErrEmailTaken).Once()
+	mockRepo.On("PlaceOrder", c, "42", books).Return(api.Order{ID: "7", Total: 2165}, nil).Once()
+	mockRepo.On("PlaceOrder", c, "43", books).Return(api.Order{}, errors.New("repository error")).Once()
+	svc := api.NewService(app, mockRepo)
+
+	assert.NoError(t, svc.CreateAccount(c, "test@example.com", "password123"))
+	assert.Error(t, svc.CreateAccount(c, "taken@example.com", "password123"))
+	_, err := svc.PlaceOrder(c, "42", books)
+	assert.NoError(t, err)
+	_, err = svc.PlaceOrder(c, "43", books)
+	assert.Error(t, err)
+
+	m := app.Metrics()
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.AccountsCreated))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.OrdersPlaced))
+	assert.Equal(t, 2165.0, testutil.ToFloat64(m.OrderValuePlacedCents))
+}
+
 func Test_Service_GetBookByID(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
@@ -222,12 +425,18 @@ func Test_Service_GetBookByID(t *testing.T) {
 			expectedBook: api.Book{},
 			expectedErr:  errors.New("repository error"),
 		},
+		{
+			name:         "Invalid ID",
+			bookID:       "abc",
+			expectedBook: api.Book{},
+			expectedErr:  api.ValidationError{Field: "id", Message: "must be a positive integer"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(mocks.Repository)
-			mockRepo.On("GetBookByID", c, tt.bookID).Return(tt.repoBook, tt.repoErr).Once()
+			mockRepo.On("GetBookByID", c, tt.bookID).Return(tt.repoBook, tt.repoErr).Maybe()
 			svc := api.NewService(app, mockRepo)
 			book, err := svc.GetBookByID(context.Background(), tt.bookID)
 			assert.Equal(t, tt.expectedBook, book)
@@ -236,6 +445,85 @@ func Test_Service_GetBookByID(t *testing.T) {
 	}
 }
 
+func Test_Service_GetBooksByIDs(t *testing.T) {
+	app := application.NewAppMock()
+	books := []api.Book{{ID: "1", Title: "Dune"}, {ID: "2", Title: "Emma"}}
+
+	tests := []struct {
+		name         string
+		bookIDs      []string
+		callsRepo    bool
+		expectedErr  error
+		expectedBook []api.Book
+	}{
+		{
+			name:         "Successful retrieval of books",
+			bookIDs:      []string{"1", "2", "3"},
+			callsRepo:    true,
+			expectedBook: books,
+		},
+		{
+			name:         "No IDs",
+			bookIDs:      nil,
+			expectedBook: []api.Book{},
+		},
+		{
+			name:        "Invalid ID",
+			bookIDs:     []string{"1", "abc"},
+			expectedErr: api.ValidationError{Field: "ids[1]", Message: "must be a positive integer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.Repository)
+			mockRepo.On("GetBooksByIDs", mock.Anything, tt.bookIDs).Return(books, nil).Maybe()
+			svc := api.NewService(app, mockRepo)
+			got, err := svc.GetBooksByIDs(context.Background(), tt.bookIDs)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.expectedBook, got)
+			}
+			if !tt.callsRepo {
+				mockRepo.AssertNotCalled(t, "GetBooksByIDs", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func Test_Service_GetUser(t *testing.T) {
+	app := application.NewAppMock()
+
+	tests := []struct {
+		name         string
+		repoUser     api.User
+		expectedUser api.User
+		expectedErr  error
+	}{
+		{
+			name:         "Password hash is not returned",
+			repoUser:     api.User{ID: "42", Email: "test@example.com", PasswordHash: "hash", Role: api.RoleCustomer},
+			expectedUser: api.User{ID: "42", Email: "test@example.com", Role: api.RoleCustomer},
+		},
+		{
+			name:        "Unknown user",
+			repoUser:    api.User{},
+			expectedErr: api.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.Repository)
+			mockRepo.On("GetUserByID", mock.Anything, "42").Return(tt.repoUser, nil).Once()
+			svc := api.NewService(app, mockRepo)
+			user, err := svc.GetUser(context.Background(), "42")
+			assert.Equal(t, tt.expectedUser, user)
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
 func Test_Service_GetUserIDByEmail(t *testing.T) {
 	app := application.NewAppMock()
 	c := context.Background()
@@ -280,3 +568,159 @@ func Test_Service_GetUserIDByEmail(t *testing.T) {
 		})
 	}
 }
+
+func Test_Service_CreateSession(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("GetUserByEmail", c, "test@example.com").Return(api.User{ID: "42", Email: "test@example.com", PasswordHash: string(hash)}, nil)
+	mockRepo.On("GetUserByID", c, "42").Return(api.User{ID: "42", Email: "test@example.com", Role: api.RoleAdmin}, nil)
+	svc := api.NewService(app, mockRepo)
+
+	session, err := svc.CreateSession(c, "test@example.com", "password123")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", session.UserID)
+	assert.NotEmpty(t, session.Token)
+
+	principal, err := svc.Authenticate(c, session.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, api.Principal{UserID: "42", Role: api.RoleAdmin}, principal)
+
+	_, err = svc.Authenticate(c, session.Token+"tampered")
+	assert.Error(t, err)
+
+	_, err = svc.CreateSession(c, "test@example.com", "wrong")
+	assert.Equal(t, api.ErrInvalidCredentials, err)
+}
+
+func Test_Service_PatchBook(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	newPrice := api.Money(1250)
+	negativeStock := -1
+	emptyTitle := ""
+	patched := api.Book{ID: "7", Title: "Dune", Author: "Frank Herbert", Description: "Spice", Price: 1250, Stock: 3}
+
+	tests := []struct {
+		name        string
+		bookID      string
+		patch       api.BookPatch
+		repoErr     error
+		wantPatch   bool
+		expectedErr error
+	}{
+		{
+			name:      "Only the given fields are sent to the repository",
+			bookID:    "7",
+			patch:     api.BookPatch{Price: &newPrice},
+			wantPatch: true,
+		},
+		{
+			name:        "Unknown book",
+			bookID:      "8",
+			patch:       api.BookPatch{Price: &newPrice},
+			repoErr:     api.ErrBookNotFound,
+			wantPatch:   true,
+			expectedErr: api.ErrBookNotFound,
+		},
+		{
+			name:        "Given fields are validated",
+			bookID:      "7",
+			patch:       api.BookPatch{Title: &emptyTitle},
+			expectedErr: api.ValidationError{Field: "title", Message: "is required"},
+		},
+		{
+			name:        "Stock must not be negative",
+			bookID:      "7",
+			patch:       api.BookPatch{Stock: &negativeStock},
+			expectedErr: api.ValidationError{Field: "stock", Message: "must not be negative"},
+		},
+		{
+			name:        "Invalid ID",
+			bookID:      "abc",
+			expectedErr: api.ValidationError{Field: "id", Message: "must be a positive integer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.Repository)
+			if tt.wantPatch {
+				result := patched
+				if tt.repoErr != nil {
+					result = api.Book{}
+				}
+				mockRepo.On("PatchBook", c, tt.bookID, tt.patch).Return(result, tt.repoErr).Once()
+			}
+			svc := api.NewService(app, mockRepo)
+			book, err := svc.PatchBook(c, tt.bookID, tt.patch)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, patched, book)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Service_SearchBooks(t *testing.T) {
+	app := application.NewAppMock()
+	c := context.Background()
+
+	mockResults := []api.BookSearchResult{{Book: api.Book{ID: "1", Title: "Dune"}, Rank: 0.6, Headline: "<b>Dune</b>"}}
+
+	tests := []struct {
+		name            string
+		search          api.BookSearch
+		repoSearch      *api.BookSearch
+		repoErr         error
+		expectedResults []api.BookSearchResult
+		expectedErr     error
+	}{
+		{
+			name:            "Query is trimmed and limit defaulted",
+			search:          api.BookSearch{Query: "  dune "},
+			repoSearch:      &api.BookSearch{Query: "dune", Limit: api.DefaultSearchLimit},
+			expectedResults: mockResults,
+		},
+		{
+			name:        "Blank query",
+			search:      api.BookSearch{Query: "   "},
+			expectedErr: api.ValidationError{Field: "q", Message: "is required"},
+		},
+		{
+			name:        "Limit too large",
+			search:      api.BookSearch{Query: "dune", Limit: api.MaxSearchLimit + 1},
+			expectedErr: api.ValidationError{Field: "limit", Message: "must be between 1 and 100"},
+		},
+		{
+			name:        "Repository error",
+			search:      api.BookSearch{Query: "dune", Limit: 5},
+			repoSearch:  &api.BookSearch{Query: "dune", Limit: 5},
+			repoErr:     errors.New("repository error"),
+			expectedErr: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewRepository(t)
+			if tt.repoSearch != nil {
+				var results []api.BookSearchResult
+				if tt.repoErr == nil {
+					results = mockResults
+				}
+				mockRepo.On("SearchBooks", c, *tt.repoSearch).Return(results, tt.repoErr).Once()
+			}
+			s := api.NewService(app, mockRepo)
+
+			results, err := s.SearchBooks(c, tt.search)
+
+			assert.Equal(t, tt.expectedResults, results)
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bookstore/internal/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService wraps every call to a Service in a span, so that traces
+// show the time spent in business logic apart from the queries it makes.
+type tracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+// NewTracedService wraps next so that its calls are traced with tp.
+func NewTracedService(next Service, tp trace.TracerProvider) Service {
+	return tracedService{next: next, tracer: tp.Tracer(tracing.InstrumentationName)}
+}
+
+func (s tracedService) GetAllBooks(ctx context.Context, query BookQuery) (_ BookPage, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetAllBooks")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllBooks(ctx, query)
+}
+
+func (s tracedService) SearchBooks(ctx context.Context, search BookSearch) (_ []BookSearchResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.SearchBooks")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SearchBooks(ctx, search)
+}
+
+func (s tracedService) CreateAccount(ctx context.Context, email, password string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.CreateAccount")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateAccount(ctx, email, password)
+}
+
+func (s tracedService) PlaceOrder(ctx context.Context, email string, books []BookOrder) (_ Order, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.PlaceOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.next.PlaceOrder(ctx, email, books)
+}
+
+func (s tracedService) GetOrderHistory(ctx context.Context, email string) (_ []Order, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetOrderHistory")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetOrderHistory(ctx, email)
+}
+
+func (s tracedService) GetUserIDByEmail(ctx context.Context, email string) (_ string, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetUserIDByEmail")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUserIDByEmail(ctx, email)
+}
+
+func (s tracedService) GetBookByID(ctx context.Context, bookID string) (_ Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetBookByID")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetBookByID(ctx, bookID)
+}
+
+func (s tracedService) GetBooksByIDs(ctx context.Context, bookIDs []string) (_ []Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetBooksByIDs")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetBooksByIDs(ctx, bookIDs)
+}
+
+func (s tracedService) GetUser(ctx context.Context, userID string) (_ User, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUser(ctx, userID)
+}
+
+func (s tracedService) VerifyCredentials(ctx context.Context, email, password string) (_ User, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.VerifyCredentials")
+	defer func() { tracing.End(span, err) }()
+	return s.next.VerifyCredentials(ctx, email, password)
+}
+
+func (s tracedService) CreateSession(ctx context.Context, email, password string) (_ Session, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.CreateSession")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateSession(ctx, email, password)
+}
+
+func (s tracedService) Authenticate(ctx context.Context, token string) (_ Principal, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.Authenticate")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Authenticate(ctx, token)
+}
+
+func (s tracedService) CreateBook(ctx context.Context, book Book) (_ Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.CreateBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateBook(ctx, book)
+}
+
+func (s tracedService) UpdateBook(ctx context.Context, book Book) (_ Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.UpdateBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.UpdateBook(ctx, book)
+}
+
+func (s tracedService) PatchBook(ctx context.Context, bookID string, patch BookPatch) (_ Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.PatchBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.PatchBook(ctx, bookID, patch)
+}
+
+func (s tracedService) DeleteBook(ctx context.Context, bookID string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.DeleteBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.DeleteBook(ctx, bookID)
+}
+
+func (s tracedService) GetCart(ctx context.Context, userID string) (_ Cart, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.GetCart")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCart(ctx, userID)
+}
+
+func (s tracedService) ReplaceCart(ctx context.Context, userID string, items []BookOrder) (_ Cart, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.ReplaceCart")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ReplaceCart(ctx, userID, items)
+}
+
+func (s tracedService) AddToCart(ctx context.Context, userID string, item BookOrder) (_ Cart, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.AddToCart")
+	defer func() { tracing.End(span, err) }()
+	return s.next.AddToCart(ctx, userID, item)
+}
+
+func (s tracedService) RemoveFromCart(ctx context.Context, userID, bookID string) (_ Cart, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.RemoveFromCart")
+	defer func() { tracing.End(span, err) }()
+	return s.next.RemoveFromCart(ctx, userID, bookID)
+}
+
+func (s tracedService) Checkout(ctx context.Context, userID string) (_ Order, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.Checkout")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Checkout(ctx, userID)
+}
+
+func (s tracedService) CancelOrder(ctx context.Context, userID, orderID string) (_ Order, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.CancelOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CancelOrder(ctx, userID, orderID)
+}
+
+func (s tracedService) TransitionOrder(ctx context.Context, actorID, orderID string, status OrderStatus) (_ Order, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.TransitionOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.next.TransitionOrder(ctx, actorID, orderID, status)
+}
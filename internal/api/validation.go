@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bookstore/internal/apperror"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLength       = 255
+	maxAuthorLength      = 255
+	maxDescriptionLength = 5000
+	maxBookPrice         = Money(100000 * minorUnitsPerMajor)
+	// maxOrderItems and maxItemQuantity bound a single order or cart; the
+	// max_items and max_quantity binding tags on OrderRequest, CartRequest
+	// and OrderItemRequest check them too.
+	maxOrderItems   = 50
+	maxItemQuantity = 100
+)
+
+// ValidationError reports a client supplied value that was rejected.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+func (e ValidationError) Unwrap() error {
+	return ValidationErrors{e}.Unwrap()
+}
+
+// ValidationErrors reports every rejected field of a request at once. Both
+// it and a single ValidationError are rendered with an "errors" list.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e ValidationErrors) Unwrap() error {
+	return apperror.Validation("validation_failed", e.Error()).With("errors", []ValidationError(e))
+}
+
+func validateBook(b Book) error {
+	return validateBookPatch(BookPatch{
+		Title:       &b.Title,
+		Author:      &b.Author,
+		Description: &b.Description,
+		Price:       &b.Price,
+		Stock:       &b.Stock,
+	})
+}
+
+// validateBookPatch checks the fields a patch sets. Every rule concerns a
+// single field, so a patch is valid exactly when the patched book would be.
+func validateBookPatch(p BookPatch) error {
+	switch {
+	case p.Title != nil && strings.TrimSpace(*p.Title) == "":
+		return ValidationError{"title", "is required"}
+	case p.Title != nil && utf8.RuneCountInString(*p.Title) > maxTitleLength:
+		return ValidationError{"title", fmt.Sprintf("must be at most %d characters", maxTitleLength)}
+	case p.Author != nil && strings.TrimSpace(*p.Author) == "":
+		return ValidationError{"author", "is required"}
+	case p.Author != nil && utf8.RuneCountInString(*p.Author) > maxAuthorLength:
+		return ValidationError{"author", fmt.Sprintf("must be at most %d characters", maxAuthorLength)}
+	case p.Description != nil && utf8.RuneCountInString(*p.Description) > maxDescriptionLength:
+		return ValidationError{"description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength)}
+	case p.Price != nil && (*p.Price < 0 || *p.Price > maxBookPrice):
+		return ValidationError{"price", fmt.Sprintf("must be between %s and %s", Money(0), maxBookPrice)}
+	case p.Stock != nil && *p.Stock < 0:
+		return ValidationError{"stock", "must not be negative"}
+	}
+	return nil
+}
+
+func validateOrderItems(books []BookOrder) error {
+	if len(books) == 0 {
+		return ValidationError{"items", "must not be empty"}
+	}
+	if len(books) > maxOrderItems {
+		return ValidationError{"items", fmt.Sprintf("must have at most %d items", maxOrderItems)}
+	}
+	for _, book := range books {
+		if err := validateID("bookId", book.BookID); err != nil {
+			return err
+		}
+		if book.Quantity <= 0 {
+			return ValidationError{"quantity", "must be positive"}
+		}
+		if book.Quantity > maxItemQuantity {
+			return ValidationError{"quantity", fmt.Sprintf("must be at most %d", maxItemQuantity)}
+		}
+	}
+	return nil
+}
+
+// validateID checks that id looks like one of our numeric primary keys.
+func validateID(field, id string) error {
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n <= 0 {
+		return ValidationError{field, "must be a positive integer"}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Views are the JSON representations returned by the handlers. They are
+// built from the domain types by the new*View functions, which decide what a
+// client gets to see.
+
+type BookView struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Stock       int    `json:"stock"`
+}
+
+func newBookView(b Book) BookView {
+	return BookView{
+		ID:          b.ID,
+		Title:       b.Title,
+		Author:      b.Author,
+		Description: b.Description,
+		Price:       b.Price,
+		Stock:       b.Stock,
+	}
+}
+
+// BookPageView is one page of GET /books. NextCursor is omitted on the last
+// page.
+type BookPageView struct {
+	Items      []BookView `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Total      int        `json:"total"`
+}
+
+func newBookPageView(p BookPage) BookPageView {
+	items := make([]BookView, len(p.Items))
+	for i, b := range p.Items {
+		items[i] = newBookView(b)
+	}
+	return BookPageView{Items: items, NextCursor: p.NextCursor, Total: p.Total}
+}
+
+type BookSearchResultView struct {
+	Book     BookView `json:"book"`
+	Rank     float64  `json:"rank"`
+	Headline string   `json:"headline"`
+}
+
+// BookSearchView is the response of GET /books/search.
+type BookSearchView struct {
+	Items []BookSearchResultView `json:"items"`
+}
+
+func newBookSearchView(results []BookSearchResult) BookSearchView {
+	items := make([]BookSearchResultView, len(results))
+	for i, r := range results {
+		items[i] = BookSearchResultView{Book: newBookView(r.Book), Rank: r.Rank, Headline: r.Headline}
+	}
+	return BookSearchView{Items: items}
+}
+
+type OrderItemView struct {
+	BookID    string `json:"bookId"`
+	Quantity  int    `json:"quantity"`
+	Title     string `json:"title"`
+	UnitPrice Money  `json:"unitPrice"`
+	LineTotal Money  `json:"lineTotal"`
+}
+
+type OrderStatusChangeView struct {
+	From      OrderStatus `json:"from,omitempty"`
+	To        OrderStatus `json:"to"`
+	ChangedBy string      `json:"changedBy,omitempty"`
+	ChangedAt time.Time   `json:"changedAt"`
+}
+
+type OrderView struct {
+	ID        string                  `json:"id"`
+	UserID    string                  `json:"userId"`
+	Status    OrderStatus             `json:"status"`
+	Items     []OrderItemView         `json:"items"`
+	Subtotal  Money                   `json:"subtotal"`
+	Tax       Money                   `json:"tax"`
+	Total     Money                   `json:"total"`
+	CreatedAt time.Time               `json:"createdAt"`
+	History   []OrderStatusChangeView `json:"history,omitempty"`
+}
+
+func newOrderView(o Order) OrderView {
+	view := OrderView{
+		ID:        o.ID,
+		UserID:    o.UserID,
+		Status:    o.Status,
+		Subtotal:  o.Subtotal,
+		Tax:       o.Tax,
+		Total:     o.Total,
+		CreatedAt: o.CreatedAt,
+	}
+	if o.Items != nil {
+		view.Items = make([]OrderItemView, len(o.Items))
+		for i, item := range o.Items {
+			view.Items[i] = OrderItemView{
+				BookID:    item.BookID,
+				Quantity:  item.Quantity,
+				Title:     item.Title,
+				UnitPrice: item.UnitPrice,
+				LineTotal: item.LineTotal,
+			}
+		}
+	}
+	for _, change := range o.History {
+		view.History = append(view.History, OrderStatusChangeView{
+			From:      change.From,
+			To:        change.To,
+			ChangedBy: change.ChangedBy,
+			ChangedAt: change.ChangedAt,
+		})
+	}
+	return view
+}
+
+func newOrderViews(orders []Order) []OrderView {
+	views := make([]OrderView, len(orders))
+	for i, o := range orders {
+		views[i] = newOrderView(o)
+	}
+	return views
+}
+
+type CartItemView struct {
+	BookID       string `json:"bookId"`
+	Title        string `json:"title"`
+	Quantity     int    `json:"quantity"`
+	UnitPrice    Money  `json:"unitPrice"`
+	LineTotal    Money  `json:"lineTotal"`
+	PriceChanged bool   `json:"priceChanged"`
+	InStock      bool   `json:"inStock"`
+}
+
+type CartView struct {
+	Items    []CartItemView `json:"items"`
+	Subtotal Money          `json:"subtotal"`
+}
+
+func newCartView(c Cart) CartView {
+	view := CartView{Items: make([]CartItemView, len(c.Items)), Subtotal: c.Subtotal}
+	for i, item := range c.Items {
+		view.Items[i] = CartItemView{
+			BookID:       item.BookID,
+			Title:        item.Title,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			LineTotal:    item.LineTotal,
+			PriceChanged: item.PriceChanged,
+			InStock:      item.InStock,
+		}
+	}
+	return view
+}
+
+type SessionView struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"userId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newSessionView(s Session) SessionView {
+	return SessionView{Token: s.Token, UserID: s.UserID, ExpiresAt: s.ExpiresAt}
+}
+
+// DatabaseStatsView reports the state of the connection pool. Durations are
+// in milliseconds.
+type DatabaseStatsView struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitDurationMs     int64 `json:"waitDurationMs"`
+	MaxIdleClosed      int64 `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64 `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed"`
+}
+
+func newDatabaseStatsView(s sql.DBStats) DatabaseStatsView {
+	return DatabaseStatsView{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
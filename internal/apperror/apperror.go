@@ -0,0 +1,128 @@
+// Package apperror classifies domain errors so that transports can report
+// them consistently without inspecting messages or database driver errors.
+package apperror
+
+import (
+	"errors"
+	"maps"
+)
+
+// Kind is the broad category of an error. It decides the response status;
+// the Code on the Error distinguishes errors of the same kind.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	// KindBadRequest is a request that could not be parsed at all.
+	KindBadRequest
+	// KindValidation is a well-formed request with unacceptable values.
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBadRequest:
+		return "bad_request"
+	case KindValidation:
+		return "validation"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	}
+	return "internal"
+}
+
+// Error is a classified error. Message is safe to show to clients; the
+// wrapped cause is not and is only meant for logs.
+type Error struct {
+	Kind Kind
+	// Code is a stable, machine readable identifier such as
+	// "book_not_found". Clients may switch on it.
+	Code    string
+	Message string
+	// Details are extra fields reported alongside the message, e.g. the
+	// IDs of the books that are out of stock.
+	Details map[string]any
+	cause   error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *Error { return New(KindBadRequest, code, message) }
+
+func Validation(code, message string) *Error { return New(KindValidation, code, message) }
+
+func NotFound(code, message string) *Error { return New(KindNotFound, code, message) }
+
+func Conflict(code, message string) *Error { return New(KindConflict, code, message) }
+
+func Unauthorized(code, message string) *Error { return New(KindUnauthorized, code, message) }
+
+func Forbidden(code, message string) *Error { return New(KindForbidden, code, message) }
+
+// Internal wraps an unexpected error. Its message never reaches clients.
+func Internal(cause error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal server error", cause: cause}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code, so that copies
+// made by Wrap and With still match the sentinel they came from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by cause.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// With returns a copy of e with an extra detail.
+func (e *Error) With(key string, value any) *Error {
+	c := *e
+	c.Details = maps.Clone(e.Details)
+	if c.Details == nil {
+		c.Details = make(map[string]any)
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// From returns the first *Error in err's chain, or an internal error wrapping
+// err when there is none.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+// KindOf returns the kind of the first *Error in err's chain.
+func KindOf(err error) Kind {
+	return From(err).Kind
+}
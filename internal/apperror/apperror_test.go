@@ -0,0 +1,65 @@
+package apperror_test
+
+import (
+	"bookstore/internal/apperror"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = apperror.NotFound("thing_not_found", "thing not found")
+
+func Test_From(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantKind    apperror.Kind
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "sentinel",
+			err:         errNotFound,
+			wantKind:    apperror.KindNotFound,
+			wantCode:    "thing_not_found",
+			wantMessage: "thing not found",
+		},
+		{
+			name:        "wrapped with context",
+			err:         fmt.Errorf("loading 7: %w", errNotFound.Wrap(sql.ErrNoRows)),
+			wantKind:    apperror.KindNotFound,
+			wantCode:    "thing_not_found",
+			wantMessage: "thing not found",
+		},
+		{
+			name:        "unclassified",
+			err:         errors.New("pq: password authentication failed"),
+			wantKind:    apperror.KindInternal,
+			wantCode:    "internal",
+			wantMessage: "internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := apperror.From(tt.err)
+			assert.Equal(t, tt.wantKind, e.Kind)
+			assert.Equal(t, tt.wantCode, e.Code)
+			assert.Equal(t, tt.wantMessage, e.Message)
+			assert.Equal(t, tt.wantKind, apperror.KindOf(tt.err))
+		})
+	}
+}
+
+func Test_Error_WrapAndWith(t *testing.T) {
+	err := errNotFound.Wrap(sql.ErrNoRows).With("id", "7")
+
+	assert.True(t, errors.Is(err, errNotFound))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.Equal(t, "thing not found: sql: no rows in result set", err.Error())
+	assert.Equal(t, map[string]any{"id": "7"}, err.Details)
+	assert.Nil(t, errNotFound.Details, "With must not modify the sentinel")
+	assert.False(t, errors.Is(err, apperror.NotFound("other_not_found", "thing not found")))
+}
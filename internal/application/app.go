@@ -1,38 +1,307 @@
 package application
 
 import (
+	"bookstore/health"
 	"bookstore/internal/application/config"
+	"bookstore/internal/logging"
+	"bookstore/internal/metrics"
+	"bookstore/internal/tracing"
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
 )
 
+// readHeaderTimeout limits how long a client may take to send request
+// headers, so idle connections cannot hold the server open.
+const readHeaderTimeout = 10 * time.Second
+
+// Application is the composition root. It owns the configuration, the
+// database pool, the logger and the servers, and runs the servers until
+// the process is asked to stop.
 type Application struct {
-	config *config.Config
+	config  *config.Config
+	db      *sql.DB
+	logger  *slog.Logger
+	health  *health.Registry
+	metrics *metrics.Metrics
+	tracer  trace.TracerProvider
+
+	handler http.Handler
+	grpc    *grpc.Server
+
+	httpServer *http.Server
+	httpAddr   net.Addr
+	serveErrs  chan error
+
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
-func Load() (*Application, error) {
-	app := Application{}
-	config, err := config.Load()
+// Load builds the logger and tracer provider described by cfg, opens the
+// database pool and waits for the database to answer, for up to
+// cfg.DBConnectTimeout. With in-memory storage there is no database and DB
+// returns nil.
+func Load(cfg *config.Config) (*Application, error) {
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel)
 	if err != nil {
-		return &app, err
+		return nil, err
+	}
+	for _, warning := range cfg.Warnings() {
+		logger.Warn(warning)
+	}
+	var tp trace.TracerProvider = noop.NewTracerProvider()
+	exporter, err := tracing.NewExporter(context.Background(), cfg.TraceExporter, cfg.TraceEndpoint, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	if exporter != nil {
+		if tp, err = tracing.NewProvider(context.Background(), exporter); err != nil {
+			return nil, err
+		}
+	}
+	var db *sql.DB
+	if cfg.Storage != config.StorageMemory {
+		if db, err = openDB(cfg, logger, tp); err != nil {
+			// The provider's exporter may hold connections and a
+			// goroutine of its own.
+			if provider, ok := tp.(interface{ Shutdown(context.Context) error }); ok {
+				ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+				defer cancel()
+				provider.Shutdown(ctx)
+			}
+			return nil, err
+		}
+	}
+	a := New(cfg, db, logger)
+	a.SetTracerProvider(tp)
+	return a, nil
+}
 
+// New assembles an Application from its parts. db may be nil for
+// applications that never touch the database, such as tests; otherwise it
+// is registered as the "database" readiness check and its pool statistics
+// are exported as metrics.
+func New(cfg *config.Config, db *sql.DB, logger *slog.Logger) *Application {
+	a := &Application{
+		config:    cfg,
+		db:        db,
+		logger:    logger,
+		health:    health.NewRegistry(),
+		metrics:   metrics.New(),
+		tracer:    noop.NewTracerProvider(),
+		serveErrs: make(chan error, 2),
+	}
+	if db != nil {
+		a.health.Register("database", 0, db.PingContext)
+		a.metrics.RegisterDBStats(db.Stats)
 	}
-	app.config = config
-	return &app, nil
+	return a
 }
 
-func NewAppMock() *Application {
-	env := map[string]string{"PORT": "8080"}
-	for k, v := range env {
-		err := os.Setenv(k, v)
+// Config returns the loaded configuration, or nil when the application was
+// not initialised.
+func (a *Application) Config() *config.Config {
+	if a == nil {
+		return nil
+	}
+	return a.config
+}
+
+// DB returns the database pool shared by the repositories.
+func (a *Application) DB() *sql.DB {
+	return a.db
+}
+
+// Logger returns the structured logger. Log with the request context where
+// there is one, so that lines carry its request ID.
+func (a *Application) Logger() *slog.Logger {
+	return a.logger
+}
+
+// Health returns the readiness checks served on /readyz. Components that
+// depend on something other than the database register their own.
+func (a *Application) Health() *health.Registry {
+	return a.health
+}
+
+// Metrics returns the Prometheus metrics served on /metrics.
+func (a *Application) Metrics() *metrics.Metrics {
+	return a.metrics
+}
+
+// TracerProvider returns the provider of the application's spans. It does
+// not record anything unless tracing is configured.
+func (a *Application) TracerProvider() trace.TracerProvider {
+	return a.tracer
+}
+
+// SetTracerProvider replaces the tracer provider, e.g. with one recording to
+// an in-memory exporter in tests. Providers with a Shutdown method, such as
+// the SDK's, are shut down last by Shutdown so that pending spans are
+// flushed.
+func (a *Application) SetTracerProvider(tp trace.TracerProvider) {
+	a.tracer = tp
+}
+
+// Handle sets the handler served on the configured HTTP port.
+func (a *Application) Handle(h http.Handler) {
+	a.handler = h
+}
+
+// ServeGRPC sets the gRPC server run on the configured gRPC port. It is not
+// started when that port is 0.
+func (a *Application) ServeGRPC(s *grpc.Server) {
+	a.grpc = s
+}
+
+// HTTPAddr returns the address the HTTP server listens on once started,
+// which tells tests the port picked for AppPort 0.
+func (a *Application) HTTPAddr() net.Addr {
+	return a.httpAddr
+}
+
+// Start binds the listeners and serves in the background. Errors binding a
+// port are returned; errors serving afterwards end Run.
+func (a *Application) Start(ctx context.Context) error {
+	if a.handler == nil {
+		return errors.New("application: no HTTP handler")
+	}
+	lc := net.ListenConfig{}
+	lis, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", a.config.AppPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for HTTP: %v", err)
+	}
+	var grpcLis net.Listener
+	if a.grpc != nil && a.config.GRPCPort != 0 {
+		grpcLis, err = lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", a.config.GRPCPort))
 		if err != nil {
-			_ = errors.New("load test case internal errror")
+			lis.Close()
+			return fmt.Errorf("failed to listen for gRPC: %v", err)
 		}
 	}
-	app, err := Load()
-	if err != nil {
-		_ = errors.New("cannot load application")
+
+	a.httpServer = &http.Server{
+		Handler:           a.handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ErrorLog:          slog.NewLogLogger(a.logger.Handler(), slog.LevelError),
+	}
+	a.httpAddr = lis.Addr()
+	a.logger.Info("Serving HTTP", "addr", lis.Addr().String())
+	go func() {
+		if err := a.httpServer.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+			a.serveErrs <- fmt.Errorf("HTTP server: %v", err)
+		}
+	}()
+	if grpcLis != nil {
+		a.logger.Info("Serving gRPC", "addr", grpcLis.Addr().String())
+		go func() {
+			if err := a.grpc.Serve(grpcLis); err != nil {
+				a.serveErrs <- fmt.Errorf("gRPC server: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Run starts the servers and blocks until ctx is cancelled, SIGINT or
+// SIGTERM is received, or a server fails. It then reports not ready, keeps
+// serving for the configured ShutdownDelay so that load balancers notice,
+// and shuts down, giving in-flight requests up to ShutdownTimeout to finish.
+func (a *Application) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.Start(ctx); err != nil {
+		a.Shutdown(context.Background())
+		return err
+	}
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Shutting down")
+	case serveErr = <-a.serveErrs:
+	}
+	// A second signal during the drain kills the process as usual.
+	stop()
+
+	a.health.SetShuttingDown()
+	if serveErr == nil && a.config.ShutdownDelay > 0 {
+		time.Sleep(a.config.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
+	defer cancel()
+	return errors.Join(serveErr, a.Shutdown(shutdownCtx))
+}
+
+// Shutdown fails readiness, stops accepting requests, waits for in-flight
+// ones to complete and closes the database pool. When ctx expires first, the
+// remaining connections are closed forcibly. Calls after the first return
+// its result.
+func (a *Application) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		a.health.SetShuttingDown()
+		var errs []error
+		if a.httpServer != nil {
+			if err := a.httpServer.Shutdown(ctx); err != nil {
+				a.httpServer.Close()
+				errs = append(errs, fmt.Errorf("HTTP server: %w", err))
+			}
+		}
+		if a.grpc != nil {
+			if err := stopGRPC(ctx, a.grpc); err != nil {
+				errs = append(errs, fmt.Errorf("gRPC server: %w", err))
+			}
+		}
+		if a.db != nil {
+			if err := a.db.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("database: %w", err))
+			}
+		}
+		if tp, ok := a.tracer.(interface{ Shutdown(context.Context) error }); ok {
+			if err := tp.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("tracing: %w", err))
+			}
+		}
+		a.shutdownErr = errors.Join(errs...)
+	})
+	return a.shutdownErr
+}
+
+// stopGRPC drains s like http.Server.Shutdown does, falling back to a hard
+// stop when ctx expires.
+func stopGRPC(ctx context.Context, s *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
 		return nil
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+		return ctx.Err()
 	}
-	return app
+}
+
+// NewAppMock returns an Application with the default configuration, no
+// database and a discarded log, for tests. It does not read or modify the
+// environment.
+func NewAppMock() *Application {
+	return New(config.Default(), nil, logging.Discard())
 }
@@ -0,0 +1,151 @@
+package application_test
+
+import (
+	"bookstore/health"
+	"bookstore/internal/application"
+	"bookstore/internal/application/config"
+	"bookstore/internal/logging"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApp(h http.Handler) *application.Application {
+	cfg := config.Default()
+	cfg.AppPort = 0
+	cfg.GRPCPort = 0
+	cfg.ShutdownTimeout = 5 * time.Second
+	app := application.New(cfg, nil, logging.Discard())
+	app.Handle(h)
+	return app
+}
+
+func Test_Shutdown_DrainsInFlightRequests(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	app := newApp(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	require.NoError(t, app.Start(context.Background()))
+	url := "http://" + app.HTTPAddr().String()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		responses <- resp
+	}()
+	<-entered
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- app.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("Shutdown returned while a request was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	require.NoError(t, <-shutdownErr)
+	resp := <-responses
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	_, err := http.Get(url)
+	assert.Error(t, err, "new connections should be refused after shutdown")
+}
+
+func Test_Shutdown_Timeout(t *testing.T) {
+	entered := make(chan struct{})
+	app := newApp(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+	}))
+	require.NoError(t, app.Start(context.Background()))
+	go http.Get("http://" + app.HTTPAddr().String())
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, app.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func Test_Run_StopsWhenContextIsCancelled(t *testing.T) {
+	app := newApp(http.NotFoundHandler())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}
+
+func Test_Run_FailsReadinessDuringShutdownDelay(t *testing.T) {
+	app := newApp(nil)
+	app.Config().ShutdownDelay = 500 * time.Millisecond
+	app.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if report := app.Health().Ready(r.Context()); report.Status != health.StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+	require.Eventually(t, func() bool { return app.HTTPAddr() != nil }, time.Second, 10*time.Millisecond)
+	url := "http://" + app.HTTPAddr().String()
+
+	cancel()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond, "readiness should fail while still serving")
+	require.NoError(t, <-done)
+}
+
+func Test_Load_GivesUpWhenTheDatabaseIsUnreachable(t *testing.T) {
+	cfg := config.Default()
+	cfg.DBHost = "127.0.0.1"
+	cfg.DBPort = 1
+	cfg.DBConnectTimeout = 300 * time.Millisecond
+
+	start := time.Now()
+	_, err := application.Load(cfg)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to database after")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func Test_Load_InMemoryStorageNeedsNoDatabase(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.DBPort = 1
+
+	app, err := application.Load(cfg)
+
+	require.NoError(t, err)
+	assert.Nil(t, app.DB())
+	assert.Equal(t, health.StatusOK, app.Health().Ready(context.Background()).Status)
+	require.NoError(t, app.Shutdown(context.Background()))
+}
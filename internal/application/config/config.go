@@ -3,60 +3,229 @@ package config
 import (
 	"errors"
 	"fmt"
-	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Config is the effective configuration. Each setting can be given, in
+// increasing order of precedence, in the config file under its yaml key,
+// in the environment variable named by its env tag or on the command line
+// with the flag named by its flag tag; see Load. Settings tagged secret are
+// redacted by Redacted, may also be read from the file named by the
+// environment variable with a _FILE suffix, and have no flag, since command
+// lines are visible to other users of the host. A setting's former
+// variable, named by its oldenv tag, is still read when the current one is
+// not set; see Warnings.
 type Config struct {
-	DBHost     string `mapstructure:"DBHOST"`
-	DBPort     int    `mapstructure:"DBPORT"`
-	DBUser     string `mapstructure:"DBPWD"`
-	DBPassword string `mapstructure:"DBPWD"`
-	DBName     string `mapstructure:"DBNAME"`
-	AppPort    int    `mapstructure:"PORT"`
+	AppPort int `yaml:"port" env:"PORT" flag:"port" usage:"HTTP port"`
+	// GRPCPort serves the gRPC API; 0 disables it.
+	GRPCPort int `yaml:"grpc_port" env:"GRPC_PORT" flag:"grpc-port" usage:"gRPC port, 0 to disable"`
+
+	// Storage keeps data in Postgres or, for development and demos, in
+	// process memory, in which case the DB_* settings are unused and
+	// everything is lost on exit.
+	Storage string `yaml:"storage" env:"STORAGE" flag:"storage" usage:"where data is kept: postgres or memory"`
+	// AdminEmail and AdminPassword create an admin account at startup
+	// with memory storage, which otherwise starts without one and so
+	// without a way to fill the catalog. In Postgres roles are assigned
+	// in the database.
+	AdminEmail    string `yaml:"admin_email" env:"ADMIN_EMAIL" flag:"admin-email" usage:"admin account created at startup with memory storage"`
+	AdminPassword string `yaml:"admin_password" env:"ADMIN_PASSWORD" secret:"true"`
+
+	DBHost     string `yaml:"db_host" env:"DB_HOST" flag:"db-host" usage:"database host"`
+	DBPort     int    `yaml:"db_port" env:"DB_PORT" oldenv:"DBPORT" flag:"db-port" usage:"database port"`
+	DBUser     string `yaml:"db_user" env:"DB_USER" flag:"db-user" usage:"database user"`
+	DBPassword string `yaml:"db_password" env:"DB_PASSWORD" secret:"true"`
+	DBName     string `yaml:"db_name" env:"DB_NAME" flag:"db-name" usage:"database name"`
+	// DBSSLMode is the lib/pq sslmode: disable, require, verify-ca or
+	// verify-full. The verify modes check the server certificate against
+	// DBSSLRootCert.
+	DBSSLMode     string `yaml:"db_sslmode" env:"DB_SSLMODE" flag:"db-sslmode" usage:"database TLS mode: disable, require, verify-ca or verify-full"`
+	DBSSLRootCert string `yaml:"db_sslrootcert" env:"DB_SSLROOTCERT" flag:"db-sslrootcert" usage:"CA certificate file for the verify sslmodes"`
+	// DBMaxOpenConns caps the pool, 0 meaning unlimited. DBMaxIdleConns
+	// connections are kept open between requests.
+	DBMaxOpenConns    int           `yaml:"db_max_open_conns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" usage:"maximum open database connections, 0 for unlimited"`
+	DBMaxIdleConns    int           `yaml:"db_max_idle_conns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" usage:"maximum idle database connections"`
+	DBConnMaxLifetime time.Duration `yaml:"db_conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" usage:"close database connections after this long, 0 to keep them"`
+	DBConnMaxIdleTime time.Duration `yaml:"db_conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time" usage:"close database connections idle for this long, 0 to keep them"`
+	// DBConnectTimeout bounds the attempts to reach the database at
+	// startup.
+	DBConnectTimeout time.Duration `yaml:"db_connect_timeout" env:"DB_CONNECT_TIMEOUT" flag:"db-connect-timeout" usage:"how long to retry reaching the database at startup"`
+	// AutoMigrate applies pending schema migrations on startup.
+	AutoMigrate bool `yaml:"db_auto_migrate" env:"DB_AUTO_MIGRATE" flag:"db-auto-migrate" usage:"apply pending migrations on startup"`
+
+	// BcryptCost is the work factor used when hashing passwords. Stored
+	// hashes with a different cost are upgraded on the next successful login.
+	BcryptCost int `yaml:"bcrypt_cost" env:"BCRYPT_COST" flag:"bcrypt-cost" usage:"bcrypt work factor for password hashes"`
+	// SessionSecret signs session tokens. When empty a random secret is
+	// generated at startup and sessions do not survive a restart.
+	SessionSecret string        `yaml:"session_secret" env:"SESSION_SECRET" secret:"true"`
+	SessionTTL    time.Duration `yaml:"session_ttl" env:"SESSION_TTL" flag:"session-ttl" usage:"session token lifetime"`
+	// TaxRateBPS is the sales tax applied to order subtotals in basis
+	// points, e.g. 825 for 8.25%.
+	TaxRateBPS int `yaml:"tax_rate_bps" env:"TAX_RATE_BPS" flag:"tax-rate-bps" usage:"sales tax in basis points"`
+
+	// ValidateRequests checks /api/v1 requests against the OpenAPI document
+	// before they reach the handlers.
+	ValidateRequests bool `yaml:"api_validate_requests" env:"API_VALIDATE_REQUESTS" flag:"api-validate-requests" usage:"validate requests against the OpenAPI document"`
+	// GraphQLMaxComplexity rejects GraphQL queries whose estimated cost
+	// exceeds it.
+	GraphQLMaxComplexity int `yaml:"graphql_max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" flag:"graphql-max-complexity" usage:"maximum cost of a GraphQL query"`
+	// ShutdownTimeout bounds how long in-flight requests are given to
+	// finish after SIGINT or SIGTERM before connections are closed.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time allowed for in-flight requests on shutdown"`
+	// LogLevel is the lowest level logged: debug, info, warn or error.
+	// LogFormat is json or text.
+	LogLevel  string `yaml:"log_level" env:"LOG_LEVEL" flag:"log-level" usage:"lowest level logged: debug, info, warn or error"`
+	LogFormat string `yaml:"log_format" env:"LOG_FORMAT" flag:"log-format" usage:"log format: json or text"`
+	// TraceExporter sends spans nowhere ("none"), to stdout or to an OTLP
+	// collector at TraceEndpoint, a URL such as http://collector:4318. The
+	// standard OTEL_EXPORTER_OTLP_* variables apply when it is empty.
+	TraceExporter string `yaml:"trace_exporter" env:"TRACE_EXPORTER" flag:"trace-exporter" usage:"where to send traces: none, stdout or otlp"`
+	TraceEndpoint string `yaml:"trace_endpoint" env:"TRACE_ENDPOINT" flag:"trace-endpoint" usage:"OTLP/HTTP collector URL for the otlp trace exporter"`
+	// ShutdownDelay keeps serving after the signal with /readyz failing, so
+	// that load balancers stop routing here before connections are closed.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"time to report not ready before shutting down"`
+
+	warnings []string
 }
 
-func Load() (*Config, error) {
-	portStr := os.Getenv("PORT")
-	if portStr == "" {
-		return nil, errors.New("PORT environment variable is not set")
-	}
+// Warnings describes deprecated environment variables Load read, to be
+// logged once a logger exists.
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
 
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse PORT: %v", err)
-	}
+// Storage backends.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
 
-	DBPORTStr := os.Getenv("DBPORT")
-	if DBPORTStr == "" {
-		return nil, errors.New("DBPORT environment variable is not set")
-	}
+var Storages = []string{StoragePostgres, StorageMemory}
 
-	DBPORT, err := strconv.Atoi(DBPORTStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse DBPORT: %v", err)
-	}
+// LogFormats and LogLevels are the values logging.New accepts.
+var (
+	LogFormats = []string{"json", "text"}
+	LogLevels  = []string{"debug", "info", "warn", "error"}
+)
+
+// TraceExporters are the kinds tracing.NewExporter accepts.
+var TraceExporters = []string{"none", "stdout", "otlp"}
+
+const (
+	DefaultBcryptCost        = 10
+	DefaultGRPCPort          = 9090
+	DefaultDBMaxOpenConns    = 25
+	DefaultDBMaxIdleConns    = 10
+	DefaultDBConnMaxLifetime = 30 * time.Minute
+	DefaultDBConnMaxIdleTime = 5 * time.Minute
+	DefaultDBConnectTimeout  = 30 * time.Second
+	// DefaultGraphQLMaxComplexity admits a full page of books with every
+	// field, or a user's order history down to the ordered books.
+	DefaultGraphQLMaxComplexity = 2000
+	DefaultSessionTTL           = 24 * time.Hour
+	DefaultShutdownTimeout      = 15 * time.Second
+	minBcryptCost               = 4
+	maxBcryptCost               = 31
+)
 
-	var c = Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     DBPORT,
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", "bookstore"),
-		AppPort:    port,
+// Default returns the configuration used for every setting that is not
+// given, with no database credentials and the HTTP server on port 8080.
+func Default() *Config {
+	return &Config{
+		Storage:              StoragePostgres,
+		DBHost:               "localhost",
+		DBPort:               5432,
+		DBUser:               "postgres",
+		DBName:               "bookstore",
+		DBSSLMode:            "disable",
+		DBMaxOpenConns:       DefaultDBMaxOpenConns,
+		DBMaxIdleConns:       DefaultDBMaxIdleConns,
+		DBConnMaxLifetime:    DefaultDBConnMaxLifetime,
+		DBConnMaxIdleTime:    DefaultDBConnMaxIdleTime,
+		DBConnectTimeout:     DefaultDBConnectTimeout,
+		AppPort:              8080,
+		GRPCPort:             DefaultGRPCPort,
+		BcryptCost:           DefaultBcryptCost,
+		SessionTTL:           DefaultSessionTTL,
+		GraphQLMaxComplexity: DefaultGraphQLMaxComplexity,
+		ShutdownTimeout:      DefaultShutdownTimeout,
+		LogLevel:             "info",
+		LogFormat:            "json",
+		TraceExporter:        "none",
 	}
-	return &c, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	value, ok := os.LookupEnv(key)
-	if !ok {
-		return defaultValue
+// Validate reports every invalid setting at once, named by its environment
+// variable.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
-	return value
+	check(c.AppPort >= 0 && c.AppPort <= 65535, "PORT must be between 0 and 65535")
+	check(c.GRPCPort >= 0 && c.GRPCPort <= 65535, "GRPC_PORT must be between 0 and 65535")
+	check(c.GRPCPort == 0 || c.GRPCPort != c.AppPort, "GRPC_PORT must differ from PORT")
+	check(slices.Contains(Storages, c.Storage), "STORAGE must be one of %s", strings.Join(Storages, ", "))
+	check(c.AdminEmail == "" || c.Storage == StorageMemory, "ADMIN_EMAIL needs STORAGE=memory")
+	check(c.AdminEmail == "" || c.AdminPassword != "", "ADMIN_PASSWORD must be set with ADMIN_EMAIL")
+	check(c.DBHost != "", "DB_HOST must be set")
+	check(c.DBPort > 0 && c.DBPort <= 65535, "DB_PORT must be between 1 and 65535")
+	check(c.DBUser != "", "DB_USER must be set")
+	check(c.DBName != "", "DB_NAME must be set")
+	check(sslModes[c.DBSSLMode], "DB_SSLMODE must be one of disable, require, verify-ca, verify-full")
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.DBMaxOpenConns == 0 || c.DBMaxIdleConns <= c.DBMaxOpenConns, "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.DBConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+	check(c.DBConnectTimeout > 0, "DB_CONNECT_TIMEOUT must be positive")
+	check(c.BcryptCost >= minBcryptCost && c.BcryptCost <= maxBcryptCost, "BCRYPT_COST must be between %d and %d", minBcryptCost, maxBcryptCost)
+	check(c.SessionTTL > 0, "SESSION_TTL must be positive")
+	check(c.TaxRateBPS >= 0 && c.TaxRateBPS <= 10000, "TAX_RATE_BPS must be between 0 and 10000")
+	check(c.GraphQLMaxComplexity > 0, "GRAPHQL_MAX_COMPLEXITY must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.ShutdownDelay >= 0, "SHUTDOWN_DELAY must not be negative")
+	check(slices.Contains(LogLevels, c.LogLevel), "LOG_LEVEL must be one of %s", strings.Join(LogLevels, ", "))
+	check(slices.Contains(LogFormats, c.LogFormat), "LOG_FORMAT must be one of %s", strings.Join(LogFormats, ", "))
+	check(slices.Contains(TraceExporters, c.TraceExporter), "TRACE_EXPORTER must be one of %s", strings.Join(TraceExporters, ", "))
+	return errors.Join(errs...)
 }
 
+// sslModes are the sslmode values lib/pq supports.
+var sslModes = map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
+
+// DBConnectionString returns the lib/pq keyword/value connection string.
+// Values are quoted so that passwords may contain spaces and quotes.
 func (c *Config) DBConnectionString() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
+	params := []struct{ key, value string }{
+		{"host", c.DBHost},
+		{"port", strconv.Itoa(c.DBPort)},
+		{"user", c.DBUser},
+		{"password", c.DBPassword},
+		{"dbname", c.DBName},
+		{"sslmode", c.DBSSLMode},
+		{"sslrootcert", c.DBSSLRootCert},
+	}
+	var b strings.Builder
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.key + "=" + quoteConnValue(p.value))
+	}
+	return b.String()
+}
+
+func quoteConnValue(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
 }
@@ -0,0 +1,295 @@
+package config_test
+
+import (
+	"bookstore/internal/application/config"
+	"bookstore/internal/logging"
+	"bookstore/internal/tracing"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var envVars = []string{
+	config.FileEnv, "PORT", "GRPC_PORT", "STORAGE", "ADMIN_EMAIL", "ADMIN_PASSWORD", "ADMIN_PASSWORD_FILE", "DB_HOST", "DB_PORT", "DBPORT", "DB_USER", "DB_PASSWORD", "DB_PASSWORD_FILE",
+	"DB_NAME", "DB_AUTO_MIGRATE", "BCRYPT_COST", "SESSION_SECRET", "SESSION_SECRET_FILE", "SESSION_TTL",
+	"TAX_RATE_BPS", "API_VALIDATE_REQUESTS", "GRAPHQL_MAX_COMPLEXITY", "SHUTDOWN_TIMEOUT",
+	"DB_SSLMODE", "DB_SSLROOTCERT", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME",
+	"DB_CONN_MAX_IDLE_TIME", "DB_CONNECT_TIMEOUT", "SHUTDOWN_DELAY",
+	"LOG_LEVEL", "LOG_FORMAT", "TRACE_EXPORTER", "TRACE_ENDPOINT",
+}
+
+// clearEnv unsets the configuration variables for the duration of a test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envVars {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Load_Precedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", "port: 7000\ndb_host: db.internal\ntax_rate_bps: 825\nsession_ttl: 1h\n")
+	t.Setenv("PORT", "7001")
+	t.Setenv("DB_AUTO_MIGRATE", "true")
+
+	cfg, args, err := config.Load([]string{"-config", path, "-port", "7002", "-api-validate-requests", "migrate", "up"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 7002, cfg.AppPort, "flags override the environment")
+	assert.Equal(t, "db.internal", cfg.DBHost, "the file overrides defaults")
+	assert.Equal(t, 825, cfg.TaxRateBPS)
+	assert.Equal(t, time.Hour, cfg.SessionTTL)
+	assert.True(t, cfg.AutoMigrate)
+	assert.True(t, cfg.ValidateRequests)
+	assert.Equal(t, config.DefaultBcryptCost, cfg.BcryptCost)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+}
+
+func Test_Load_FileFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(config.FileEnv, writeFile(t, "config.yml", "db_name: shop\n"))
+
+	cfg, _, err := config.Load(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "shop", cfg.DBName)
+}
+
+func Test_Load_SecretFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantPassword string
+		wantErr      string
+	}{
+		{
+			name:         "from_file",
+			env:          map[string]string{"DB_PASSWORD_FILE": "s3cret\n"},
+			wantPassword: "s3cret",
+		},
+		{
+			name:         "from_env",
+			env:          map[string]string{"DB_PASSWORD": "plain"},
+			wantPassword: "plain",
+		},
+		{
+			name:    "both",
+			env:     map[string]string{"DB_PASSWORD": "plain", "DB_PASSWORD_FILE": "s3cret"},
+			wantErr: "only one of DB_PASSWORD and DB_PASSWORD_FILE may be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				if strings.HasSuffix(k, "_FILE") {
+					v = writeFile(t, "secret", v)
+				}
+				t.Setenv(k, v)
+			}
+
+			cfg, _, err := config.Load(nil)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPassword, cfg.DBPassword)
+		})
+	}
+}
+
+func Test_Load_DeprecatedEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantPort     int
+		wantWarnings []string
+	}{
+		{
+			name:         "old_name",
+			env:          map[string]string{"DBPORT": "6543"},
+			wantPort:     6543,
+			wantWarnings: []string{"DBPORT is deprecated, use DB_PORT"},
+		},
+		{
+			name:         "both_names",
+			env:          map[string]string{"DBPORT": "6543", "DB_PORT": "7654"},
+			wantPort:     7654,
+			wantWarnings: []string{"DBPORT is deprecated and ignored since DB_PORT is set"},
+		},
+		{
+			name:     "new_name",
+			env:      map[string]string{"DB_PORT": "7654"},
+			wantPort: 7654,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, _, err := config.Load(nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPort, cfg.DBPort)
+			assert.Equal(t, tt.wantWarnings, cfg.Warnings())
+		})
+	}
+}
+
+func Test_Load_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "invalid_env_value",
+			env:     map[string]string{"DB_PORT": "five"},
+			wantErr: []string{"failed to parse DB_PORT: not an integer"},
+		},
+		{
+			name:    "secrets_have_no_flags",
+			args:    []string{"-db-password", "s3cret"},
+			wantErr: []string{"flag provided but not defined: -db-password"},
+		},
+		{
+			name:    "invalid_deprecated_env_value",
+			env:     map[string]string{"DBPORT": "five"},
+			wantErr: []string{"failed to parse DBPORT: not an integer"},
+		},
+		{
+			name:    "invalid_flag_value",
+			args:    []string{"-session-ttl", "forever"},
+			wantErr: []string{`invalid value "forever" for flag -session-ttl`},
+		},
+		{
+			name:    "unknown_file_key",
+			file:    "port: 8080\ndbport: 5432\n",
+			wantErr: []string{"field dbport not found"},
+		},
+		{
+			name:    "admin_needs_memory_storage",
+			env:     map[string]string{"ADMIN_EMAIL": "admin@example.com", "ADMIN_PASSWORD": "s3cret"},
+			wantErr: []string{"ADMIN_EMAIL needs STORAGE=memory"},
+		},
+		{
+			name:    "admin_needs_password",
+			env:     map[string]string{"STORAGE": "memory", "ADMIN_EMAIL": "admin@example.com"},
+			wantErr: []string{"ADMIN_PASSWORD must be set with ADMIN_EMAIL"},
+		},
+		{
+			name: "all_invalid_values_are_reported",
+			env:  map[string]string{"PORT": "70000", "BCRYPT_COST": "3", "TAX_RATE_BPS": "-1", "DB_SSLMODE": "prefer", "DB_MAX_IDLE_CONNS": "50", "LOG_LEVEL": "verbose", "STORAGE": "sqlite"},
+			wantErr: []string{
+				"PORT must be between 0 and 65535",
+				"BCRYPT_COST must be between 4 and 31",
+				"TAX_RATE_BPS must be between 0 and 10000",
+				"DB_SSLMODE must be one of disable, require, verify-ca, verify-full",
+				"DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS",
+				"LOG_LEVEL must be one of debug, info, warn, error",
+				"STORAGE must be one of postgres, memory",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.yaml", tt.file)}, args...)
+			}
+
+			_, _, err := config.Load(args)
+
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func Test_Write_RedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.DBPassword = "s3cret"
+	cfg.SessionSecret = "signing-key"
+
+	var b strings.Builder
+	require.NoError(t, cfg.Write(&b))
+
+	out := b.String()
+	assert.NotContains(t, out, "s3cret")
+	assert.NotContains(t, out, "signing-key")
+	assert.Contains(t, out, "db_password: REDACTED\n")
+	assert.Contains(t, out, "session_ttl: 24h0m0s\n")
+	assert.Equal(t, "s3cret", cfg.DBPassword, "Write must not modify the config")
+
+	// The output can be read back as a config file.
+	clearEnv(t)
+	loaded, _, err := config.Load([]string{"-config", writeFile(t, "config.yaml", out)})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.SessionTTL, loaded.SessionTTL)
+}
+
+func Test_Load_ExampleFile(t *testing.T) {
+	clearEnv(t)
+
+	cfg, _, err := config.Load([]string{"-config", "../../../config.example.yaml"})
+
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+}
+
+// Test_AllowedValues keeps the lists Validate checks in step with what the
+// logging and tracing packages accept.
+func Test_AllowedValues(t *testing.T) {
+	for _, format := range config.LogFormats {
+		for _, level := range config.LogLevels {
+			_, err := logging.New(io.Discard, format, level)
+			assert.NoError(t, err, "%s %s", format, level)
+		}
+	}
+	for _, kind := range config.TraceExporters {
+		_, err := tracing.NewExporter(context.Background(), kind, "", io.Discard)
+		assert.NoError(t, err, kind)
+	}
+}
+
+func Test_DBConnectionString(t *testing.T) {
+	cfg := config.Default()
+	cfg.DBPassword = `it's a \secret`
+	cfg.DBSSLMode = "verify-full"
+	cfg.DBSSLRootCert = "/etc/ssl/db-ca.pem"
+
+	assert.Equal(t,
+		`host='localhost' port='5432' user='postgres' password='it\'s a \\secret' dbname='bookstore' sslmode='verify-full' sslrootcert='/etc/ssl/db-ca.pem'`,
+		cfg.DBConnectionString())
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when the -config flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// redacted replaces the value of secret settings in Redacted.
+const redacted = "REDACTED"
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML file named by -config or $CONFIG_FILE, the
+// environment and the flags in args, and validates the result. It also
+// returns the arguments left after the flags, such as a subcommand.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	path := fs.String("config", "", "YAML config file (default $"+FileEnv+")")
+	var fromFlags []func(*Config)
+	for _, s := range settings() {
+		s := s
+		if s.secret && s.flag != "" {
+			panic("config: secret setting " + s.env + " must not have a flag")
+		}
+		if s.flag == "" {
+			continue
+		}
+		parse := func(v string) error {
+			value, err := s.parse(v)
+			if err != nil {
+				return err
+			}
+			fromFlags = append(fromFlags, func(c *Config) { s.field(c).Set(value) })
+			return nil
+		}
+		if s.typ.Kind() == reflect.Bool {
+			fs.BoolFunc(s.flag, s.usage, parse)
+		} else {
+			fs.Func(s.flag, s.usage, parse)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	c := Default()
+	if *path == "" {
+		*path = os.Getenv(FileEnv)
+	}
+	if *path != "" {
+		if err := c.readFile(*path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := c.readEnv(); err != nil {
+		return nil, nil, err
+	}
+	for _, apply := range fromFlags {
+		apply(c)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+func (c *Config) readFile(path string) error {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+	default:
+		return fmt.Errorf("config file %s: only YAML files are supported", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return nil
+}
+
+// readEnv applies the environment variables that are set. A secret may
+// instead be read from the file named by its variable with a _FILE suffix,
+// as Docker and Kubernetes secrets are mounted; trailing newlines are
+// dropped. A setting's former variable is used, with a warning, when its
+// current one is not set.
+func (c *Config) readEnv() error {
+	for _, s := range settings() {
+		name := s.env
+		v, ok := os.LookupEnv(name)
+		if old, oldOK := os.LookupEnv(s.oldEnv); s.oldEnv != "" && oldOK {
+			if ok {
+				c.warnings = append(c.warnings, fmt.Sprintf("%s is deprecated and ignored since %s is set", s.oldEnv, s.env))
+			} else {
+				c.warnings = append(c.warnings, fmt.Sprintf("%s is deprecated, use %s", s.oldEnv, s.env))
+				name, v, ok = s.oldEnv, old, true
+			}
+		}
+		if s.secret {
+			if file, fileOK := os.LookupEnv(s.env + "_FILE"); fileOK {
+				if ok {
+					return fmt.Errorf("only one of %s and %s_FILE may be set", s.env, s.env)
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return fmt.Errorf("failed to read %s_FILE: %v", s.env, err)
+				}
+				v, ok = strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+		if !ok {
+			continue
+		}
+		value, err := s.parse(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		s.field(c).Set(value)
+	}
+	return nil
+}
+
+// Redacted returns a copy of c with the secrets that are set replaced, for
+// logging or display.
+func (c *Config) Redacted() *Config {
+	r := *c
+	for _, s := range settings() {
+		if f := s.field(&r); s.secret && f.String() != "" {
+			f.SetString(redacted)
+		}
+	}
+	return &r
+}
+
+// Write prints the redacted configuration as YAML, in the format read from
+// the config file.
+func (c *Config) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// setting describes a Config field from its struct tags.
+type setting struct {
+	index  int
+	typ    reflect.Type
+	env    string
+	oldEnv string
+	flag   string
+	usage  string
+	secret bool
+}
+
+func settings() []setting {
+	t := reflect.TypeOf(Config{})
+	list := make([]setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		list = append(list, setting{
+			index:  i,
+			typ:    f.Type,
+			env:    f.Tag.Get("env"),
+			oldEnv: f.Tag.Get("oldenv"),
+			flag:   f.Tag.Get("flag"),
+			usage:  f.Tag.Get("usage"),
+			secret: f.Tag.Get("secret") == "true",
+		})
+	}
+	return list
+}
+
+func (s setting) field(c *Config) reflect.Value {
+	return reflect.ValueOf(c).Elem().Field(s.index)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parse converts the text of an environment variable or flag to the type
+// of the setting.
+func (s setting) parse(v string) (reflect.Value, error) {
+	switch {
+	case s.typ == durationType:
+		d, err := time.ParseDuration(v)
+		return reflect.ValueOf(d), err
+	case s.typ.Kind() == reflect.String:
+		return reflect.ValueOf(v), nil
+	case s.typ.Kind() == reflect.Int:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return reflect.Value{}, errors.New("not an integer")
+		}
+		return reflect.ValueOf(i), nil
+	case s.typ.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return reflect.Value{}, errors.New("not a boolean")
+		}
+		return reflect.ValueOf(b), nil
+	}
+	panic("config: unsupported setting type " + s.typ.String())
+}
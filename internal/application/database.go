@@ -0,0 +1,91 @@
+package application
+
+import (
+	"bookstore/internal/application/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Delays between attempts to reach the database at startup: the first retry
+// waits connectRetryMin and each one after doubles, up to connectRetryMax.
+const (
+	connectRetryMin = 100 * time.Millisecond
+	connectRetryMax = 5 * time.Second
+)
+
+// openDB opens the pool described by cfg and waits until the database
+// answers, so that a wrong password or host fails at startup rather than on
+// the first request. Statements are traced with tp; their arguments are not
+// recorded.
+func openDB(cfg *config.Config, logger *slog.Logger, tp trace.TracerProvider) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DBConnectionString(),
+		otelsql.WithTracerProvider(tp),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+	defer cancel()
+	if err := connect(ctx, db, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connect pings db with exponential backoff until it answers, ctx expires
+// or the error shows that retrying cannot help.
+func connect(ctx context.Context, db *sql.DB, logger *slog.Logger) error {
+	delay := connectRetryMin
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if permanentDBError(err) {
+			return fmt.Errorf("failed to connect to database: %v", err)
+		}
+		logger.Warn("Database not ready", "attempt", attempt, "error", err, "retry_in", delay.String())
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to connect to database after %d attempts: %v", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectRetryMax)
+	}
+}
+
+// permanentDBError reports errors that will not go away by waiting: rejected
+// credentials and unknown databases.
+func permanentDBError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code.Class() == "28" || pqErr.Code == "3D000"
+}
+
+// DBStats returns the statistics of the database pool, or zero values when
+// the application has no database.
+func (a *Application) DBStats() sql.DBStats {
+	if a == nil || a.db == nil {
+		return sql.DBStats{}
+	}
+	return a.db.Stats()
+}
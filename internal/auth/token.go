@@ -0,0 +1,87 @@
+// Package auth issues and verifies the signed session tokens handed out by
+// POST /sessions.
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "bookstore"
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or were
+// not signed with our secret.
+var ErrInvalidToken = errors.New("invalid or expired session token")
+
+type Claims struct {
+	UserID string
+	// ExpiresAt is when the token stops being accepted.
+	ExpiresAt time.Time
+}
+
+// TokenManager signs HS256 JWTs whose subject is the user ID.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewTokenManager(secret []byte, ttl time.Duration) *TokenManager {
+	return &TokenManager{
+		secret: secret,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// RandomSecret returns a fresh 256-bit signing key. Tokens signed with it do
+// not survive a restart, so it is only suitable for development.
+func RandomSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate session secret: %v", err)
+	}
+	return secret, nil
+}
+
+func (m *TokenManager) Issue(userID string) (string, Claims, error) {
+	now := m.now()
+	claims := Claims{
+		UserID:    userID,
+		ExpiresAt: now.Add(m.ttl),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   userID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+	})
+	signed, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", Claims{}, fmt.Errorf("failed to sign session token: %v", err)
+	}
+	return signed, claims, nil
+}
+
+func (m *TokenManager) Verify(tokenString string) (Claims, error) {
+	var registered jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(tokenString, &registered, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil || registered.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	return Claims{
+		UserID:    registered.Subject,
+		ExpiresAt: registered.ExpiresAt.Time,
+	}, nil
+}
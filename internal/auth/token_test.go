@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenManager(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewTokenManager([]byte("secret"), time.Hour)
+	m.now = func() time.Time { return now }
+
+	token, claims, err := m.Issue("42")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+	assert.Equal(t, now.Add(time.Hour), claims.ExpiresAt)
+
+	tests := []struct {
+		name    string
+		manager *TokenManager
+		token   string
+		at      time.Time
+		wantErr error
+	}{
+		{
+			name:    "valid token",
+			manager: m,
+			token:   token,
+			at:      now.Add(time.Minute),
+		},
+		{
+			name:    "expired token",
+			manager: m,
+			token:   token,
+			at:      now.Add(2 * time.Hour),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "wrong secret",
+			manager: NewTokenManager([]byte("other"), time.Hour),
+			token:   token,
+			at:      now,
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "garbage",
+			manager: m,
+			token:   "not-a-token",
+			at:      now,
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			tt.manager.now = func() time.Time { return at }
+			got, err := tt.manager.Verify(tt.token)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "42", got.UserID)
+			}
+		})
+	}
+}
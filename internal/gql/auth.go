@@ -0,0 +1,59 @@
+package gql
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/auth"
+	"bookstore/internal/logging"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type principalKey struct{}
+
+// principalFrom returns the authenticated caller, or a zero Principal for
+// anonymous requests.
+func principalFrom(ctx context.Context) api.Principal {
+	principal, _ := ctx.Value(principalKey{}).(api.Principal)
+	return principal
+}
+
+// optionalAuth resolves a bearer token to a Principal on the request
+// context. Unlike api.RequireAuth it lets anonymous requests through: the
+// catalog is public and the resolvers of private fields check the caller
+// themselves. A token that is sent but invalid is still rejected.
+func optionalAuth(service api.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.Next()
+			return
+		}
+		principal, err := service.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidToken) {
+				err = api.ErrInvalidSession.Wrap(err)
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		logging.SetUserID(c.Request.Context(), principal.UserID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalKey{}, principal))
+		c.Next()
+	}
+}
+
+// requireOwnerOrPermission allows the user userID and callers holding perm.
+func requireOwnerOrPermission(ctx context.Context, userID string, perm api.Permission) error {
+	principal := principalFrom(ctx)
+	if principal.UserID == "" {
+		return api.ErrAuthRequired
+	}
+	if principal.UserID != userID && !api.Allowed(principal.Role, perm) {
+		return api.ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package gql
+
+import (
+	"bookstore/internal/api"
+	"context"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+)
+
+// loaderWait is how long a loader collects keys before fetching them. The
+// fields of a list are resolved concurrently, so a short wait is enough to
+// gather every book of a response into one query.
+const loaderWait = 2 * time.Millisecond
+
+// loaders batch the lookups made while resolving one request. They cache
+// what they fetch, so they must not outlive the request.
+type loaders struct {
+	books *dataloadgen.Loader[string, api.Book]
+}
+
+func newLoaders(service api.Service) *loaders {
+	return &loaders{
+		books: dataloadgen.NewLoader(fetchBooks(service), dataloadgen.WithWait(loaderWait)),
+	}
+}
+
+// fetchBooks resolves a batch of book IDs with a single GetBooksByIDs call.
+// Books that no longer exist fail with api.ErrBookNotFound.
+func fetchBooks(service api.Service) func(ctx context.Context, ids []string) ([]api.Book, []error) {
+	return func(ctx context.Context, ids []string) ([]api.Book, []error) {
+		found, err := service.GetBooksByIDs(ctx, ids)
+		if err != nil {
+			return nil, []error{err}
+		}
+		byID := make(map[string]api.Book, len(found))
+		for _, book := range found {
+			byID[book.ID] = book
+		}
+		books := make([]api.Book, len(ids))
+		errs := make([]error, len(ids))
+		for i, id := range ids {
+			book, ok := byID[id]
+			if !ok {
+				errs[i] = api.ErrBookNotFound
+				continue
+			}
+			books[i] = book
+		}
+		return books, errs
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// primeBooks records books a resolver already fetched so that later lookups
+// of the same IDs do not query again.
+func primeBooks(ctx context.Context, books ...api.Book) {
+	l := loadersFrom(ctx)
+	for _, book := range books {
+		l.books.Prime(book.ID, book)
+	}
+}
@@ -0,0 +1,16 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package gql
+
+import (
+	"bookstore/internal/api"
+)
+
+type BookConnection struct {
+	Nodes      []api.Book `json:"nodes"`
+	NextCursor *string    `json:"nextCursor,omitempty"`
+	TotalCount int        `json:"totalCount"`
+}
+
+type Query struct {
+}
@@ -0,0 +1,26 @@
+package gql
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+)
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.49 generate
+
+// Resolver answers GraphQL queries from the same Service the REST and gRPC
+// APIs use. Books referenced by order items are fetched through the
+// per-request loaders in loaders.go.
+type Resolver struct {
+	app     *application.Application
+	service api.Service
+}
+
+// value dereferences an optional argument, using the zero value when it was
+// not given.
+func value[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package gql
+
+import (
+	"bookstore/internal/api"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// The functions in this file bind GraphQL scalars and enums to the api types
+// so that resolvers can return domain models unchanged.
+
+// MarshalMoney writes an amount as a decimal string, as the REST API does.
+func MarshalMoney(m api.Money) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(m.String()))
+	})
+}
+
+// UnmarshalMoney accepts "12.34" as well as a bare number.
+func UnmarshalMoney(v any) (api.Money, error) {
+	switch v := v.(type) {
+	case string:
+		return api.ParseMoney(v)
+	case int64:
+		return api.ParseMoney(strconv.FormatInt(v, 10))
+	case float64:
+		return api.ParseMoney(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return 0, fmt.Errorf("%T is not an amount", v)
+}
+
+// MarshalOrderStatus writes the upper-case enum value; the zero status, such
+// as the source of an order's first history entry, is null.
+func MarshalOrderStatus(s api.OrderStatus) graphql.Marshaler {
+	if s == "" {
+		return graphql.Null
+	}
+	return graphql.MarshalString(strings.ToUpper(string(s)))
+}
+
+func UnmarshalOrderStatus(v any) (api.OrderStatus, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%T is not an order status", v)
+	}
+	status := api.OrderStatus(strings.ToLower(s))
+	if !status.Valid() {
+		return "", fmt.Errorf("%q is not an order status", s)
+	}
+	return status, nil
+}
+
+func MarshalBookSort(s api.BookSort) graphql.Marshaler {
+	return graphql.MarshalString(strings.ToUpper(string(s)))
+}
+
+func UnmarshalBookSort(v any) (api.BookSort, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%T is not a book sort", v)
+	}
+	sort := api.BookSort(strings.ToLower(s))
+	if !sort.Valid() {
+		return "", fmt.Errorf("%q is not a book sort", s)
+	}
+	return sort, nil
+}
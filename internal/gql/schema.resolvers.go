@@ -0,0 +1,131 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"bookstore/internal/api"
+	"context"
+	"errors"
+)
+
+// Book is the resolver for the book field.
+func (r *orderItemResolver) Book(ctx context.Context, obj *api.BookOrder) (api.Book, error) {
+	return loadersFrom(ctx).books.Load(ctx, obj.BookID)
+}
+
+// Book is the resolver for the book field.
+func (r *queryResolver) Book(ctx context.Context, id string) (*api.Book, error) {
+	lookup := struct {
+		ID string `json:"id" binding:"required,id"`
+	}{id}
+	if err := api.Validate(lookup); err != nil {
+		return nil, err
+	}
+	book, err := r.service.GetBookByID(ctx, id)
+	if errors.Is(err, api.ErrBookNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	primeBooks(ctx, book)
+	return &book, nil
+}
+
+// Books is the resolver for the books field.
+func (r *queryResolver) Books(ctx context.Context, first *int, after *string, sort *api.BookSort, descending *bool, author *string, inStock *bool) (BookConnection, error) {
+	query := api.BookQuery{
+		Sort:    value(sort),
+		Desc:    value(descending),
+		Author:  value(author),
+		InStock: inStock,
+		Cursor:  value(after),
+		Limit:   listSize(first, api.DefaultBookPageSize),
+	}
+	page, err := r.service.GetAllBooks(ctx, query)
+	if err != nil {
+		return BookConnection{}, err
+	}
+	primeBooks(ctx, page.Items...)
+	conn := BookConnection{Nodes: page.Items, TotalCount: page.Total}
+	if page.NextCursor != "" {
+		conn.NextCursor = &page.NextCursor
+	}
+	return conn, nil
+}
+
+// SearchBooks is the resolver for the searchBooks field.
+func (r *queryResolver) SearchBooks(ctx context.Context, query string, limit *int) ([]api.BookSearchResult, error) {
+	results, err := r.service.SearchBooks(ctx, api.BookSearch{Query: query, Limit: value(limit)})
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		primeBooks(ctx, result.Book)
+	}
+	return results, nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*api.User, error) {
+	principal := principalFrom(ctx)
+	if principal.UserID == "" {
+		return nil, nil
+	}
+	user, err := r.service.GetUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, email string) (*api.User, error) {
+	if principalFrom(ctx).UserID == "" {
+		return nil, api.ErrAuthRequired
+	}
+	lookup := struct {
+		Email string `json:"email" binding:"required,email"`
+	}{email}
+	if err := api.Validate(lookup); err != nil {
+		return nil, err
+	}
+	userID, err := r.service.GetUserIDByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, nil
+	}
+	return &api.User{ID: userID, Email: email}, nil
+}
+
+// Orders is the resolver for the orders field.
+func (r *userResolver) Orders(ctx context.Context, obj *api.User) ([]api.Order, error) {
+	if err := requireOwnerOrPermission(ctx, obj.ID, api.PermManageOrders); err != nil {
+		return nil, err
+	}
+	orders, err := r.service.GetOrderHistory(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) > maxUserOrders {
+		orders = orders[:maxUserOrders]
+	}
+	return orders, nil
+}
+
+// OrderItem returns OrderItemResolver implementation.
+func (r *Resolver) OrderItem() OrderItemResolver { return &orderItemResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type orderItemResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
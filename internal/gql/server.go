@@ -0,0 +1,129 @@
+package gql
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/apperror"
+	"bookstore/internal/application"
+	"bookstore/internal/application/config"
+	"bookstore/internal/logging"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Path is where the GraphQL endpoint is served. Like the health probes it is not
+// versioned: GraphQL schemas evolve by deprecating fields instead.
+const Path = "/graphql"
+
+// unboundedListSize is the length the complexity estimate assumes for lists
+// that take no size argument, such as an order's items.
+const unboundedListSize = 10
+
+// maxUserOrders caps User.orders, which takes no size argument, to the
+// user's newest orders.
+const maxUserOrders = 20
+
+// Register serves queries at Path over GET and POST, and a GraphiQL
+// playground at Path/playground. A bearer token is optional; see optionalAuth.
+func Register(r gin.IRouter, app *application.Application, service api.Service) {
+	handlers := gin.HandlersChain{optionalAuth(service), gin.WrapH(NewHandler(app, service))}
+	r.GET(Path, handlers...)
+	r.POST(Path, handlers...)
+	r.GET(Path+"/playground", gin.WrapH(playground.Handler("Bookstore", Path)))
+}
+
+// NewHandler executes GraphQL queries against service. Each request gets
+// its own loaders, and queries whose estimated cost exceeds the configured
+// GraphQLMaxComplexity are rejected before any resolver runs.
+func NewHandler(app *application.Application, service api.Service) http.Handler {
+	maxComplexity := config.DefaultGraphQLMaxComplexity
+	if cfg := app.Config(); cfg != nil && cfg.GraphQLMaxComplexity > 0 {
+		maxComplexity = cfg.GraphQLMaxComplexity
+	}
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{app: app, service: service},
+		Complexity: complexity(),
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	srv.SetErrorPresenter(presentError)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := withLoaders(r.Context(), newLoaders(service))
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// complexity weighs list fields by the number of elements they may return,
+// so that a query's cost tracks the rows it can load rather than the number
+// of fields it names.
+func complexity() ComplexityRoot {
+	var c ComplexityRoot
+	c.Query.Books = func(childComplexity int, first *int, _ *string, _ *api.BookSort, _ *bool, _ *string, _ *bool) int {
+		return 1 + childComplexity*listSize(first, api.DefaultBookPageSize)
+	}
+	c.Query.SearchBooks = func(childComplexity int, _ string, limit *int) int {
+		return 1 + childComplexity*listSize(limit, api.DefaultSearchLimit)
+	}
+	c.User.Orders = func(childComplexity int) int {
+		return 1 + childComplexity*maxUserOrders
+	}
+	c.Order.Items = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Order.History = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	return c
+}
+
+func listSize(n *int, defaultSize int) int {
+	switch {
+	case n == nil:
+		return defaultSize
+	case *n < 1:
+		return 1
+	}
+	return *n
+}
+
+// presentError reports resolver errors the way api.ErrorHandler does: the
+// apperror message with its code, details and the request ID as extensions.
+// Internal errors are logged and replaced by a generic message. Errors raised
+// by gqlgen itself, such as syntax or complexity errors, are passed through.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if gqlErr.Err == nil {
+		return gqlErr
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		gqlErr.Message = err.Error()
+		return gqlErr
+	}
+	appErr := apperror.From(gqlErr.Err)
+	if appErr.Kind == apperror.KindInternal {
+		logging.FromContext(ctx).ErrorContext(ctx, "Error resolving field", "path", gqlErr.Path.String(), "error", gqlErr.Err)
+	}
+	gqlErr.Message = appErr.Message
+	gqlErr.Extensions = make(map[string]any, len(appErr.Details)+2)
+	for k, v := range appErr.Details {
+		gqlErr.Extensions[k] = v
+	}
+	gqlErr.Extensions["code"] = appErr.Code
+	if id := logging.RequestID(ctx); id != "" {
+		gqlErr.Extensions["requestId"] = id
+	}
+	return gqlErr
+}
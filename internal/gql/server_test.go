@@ -0,0 +1,276 @@
+package gql_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"bookstore/internal/gql"
+	"bookstore/internal/logging"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// do posts query to a router serving service and decodes the response.
+func do(t *testing.T, service api.Service, token, query string) (int, gqlResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logging.Middleware(logging.Discard()), api.ErrorHandler())
+	gql.Register(r, application.NewAppMock(), service)
+
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, gql.Path, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp gqlResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func errorCodes(resp gqlResponse) []string {
+	var codes []string
+	for _, e := range resp.Errors {
+		code, _ := e.Extensions["code"].(string)
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func Test_Books(t *testing.T) {
+	mockService := new(mocks.Service)
+	inStock := true
+	mockService.On("GetAllBooks", mock.Anything, api.BookQuery{
+		Sort: api.SortByPrice, Desc: true, InStock: &inStock, Limit: 2,
+	}).Return(api.BookPage{
+		Items:      []api.Book{{ID: "1", Title: "Dune", Price: 1299}, {ID: "2", Title: "Emma", Price: 999}},
+		NextCursor: "next",
+		Total:      5,
+	}, nil).Once()
+
+	code, resp := do(t, mockService, "", `{
+		books(first: 2, sort: PRICE, descending: true, inStock: true) {
+			nodes { id title price }
+			nextCursor
+			totalCount
+		}
+	}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"books":{
+		"nodes":[{"id":"1","title":"Dune","price":"12.99"},{"id":"2","title":"Emma","price":"9.99"}],
+		"nextCursor":"next",
+		"totalCount":5
+	}}`, string(resp.Data))
+	mockService.AssertExpectations(t)
+}
+
+func Test_OrderItemBooksAreBatched(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil)
+	mockService.On("GetUser", mock.Anything, "42").Return(api.User{ID: "42", Email: "reader@example.com"}, nil)
+	mockService.On("GetOrderHistory", mock.Anything, "42").Return([]api.Order{
+		{ID: "10", Status: api.OrderShipped, Items: []api.BookOrder{{BookID: "1", Quantity: 1}, {BookID: "2", Quantity: 2}}, CreatedAt: time.Now()},
+		{ID: "9", Status: api.OrderCancelled, Items: []api.BookOrder{{BookID: "1", Quantity: 3}}, CreatedAt: time.Now()},
+	}, nil)
+	var fetched [][]string
+	mockService.On("GetBooksByIDs", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ids := append([]string(nil), args.Get(1).([]string)...)
+		sort.Strings(ids)
+		fetched = append(fetched, ids)
+	}).Return([]api.Book{{ID: "1", Title: "Dune"}, {ID: "2", Title: "Emma"}}, nil)
+
+	code, resp := do(t, mockService, "valid-token", `{
+		me { email orders { id status items { quantity book { title } } } }
+	}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"me":{"email":"reader@example.com","orders":[
+		{"id":"10","status":"SHIPPED","items":[{"quantity":1,"book":{"title":"Dune"}},{"quantity":2,"book":{"title":"Emma"}}]},
+		{"id":"9","status":"CANCELLED","items":[{"quantity":3,"book":{"title":"Dune"}}]}
+	]}}`, string(resp.Data))
+	assert.Equal(t, [][]string{{"1", "2"}}, fetched, "all books should be fetched in one call")
+}
+
+func Test_UserOrders_Authorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		principal api.Principal
+		wantCodes []string
+	}{
+		{
+			name:      "anonymous",
+			wantCodes: []string{"authentication_required"},
+		},
+		{
+			name:      "other_customer",
+			token:     "valid-token",
+			principal: api.Principal{UserID: "7", Role: api.RoleCustomer},
+			wantCodes: []string{"insufficient_permissions"},
+		},
+		{
+			name:      "owner",
+			token:     "valid-token",
+			principal: api.Principal{UserID: "42", Role: api.RoleCustomer},
+		},
+		{
+			name:      "staff",
+			token:     "valid-token",
+			principal: api.Principal{UserID: "7", Role: api.RoleStaff},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", mock.Anything, "valid-token").Return(tt.principal, nil).Maybe()
+			mockService.On("GetUserIDByEmail", mock.Anything, "reader@example.com").Return("42", nil)
+			mockService.On("GetOrderHistory", mock.Anything, "42").Return([]api.Order{}, nil).Maybe()
+
+			code, resp := do(t, mockService, tt.token, `{ user(email: "reader@example.com") { id orders { id } } }`)
+
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.wantCodes, errorCodes(resp))
+			if tt.wantCodes == nil {
+				assert.JSONEq(t, `{"user":{"id":"42","orders":[]}}`, string(resp.Data))
+			} else {
+				mockService.AssertNotCalled(t, "GetOrderHistory", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func Test_UserLookup_RequiresAuth(t *testing.T) {
+	mockService := new(mocks.Service)
+
+	code, resp := do(t, mockService, "", `{ user(email: "reader@example.com") { id } }`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"user":null}`, string(resp.Data))
+	assert.Equal(t, []string{"authentication_required"}, errorCodes(resp))
+	mockService.AssertNotCalled(t, "GetUserIDByEmail", mock.Anything, mock.Anything)
+}
+
+func Test_UserOrders_Capped(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil)
+	mockService.On("GetUser", mock.Anything, "42").Return(api.User{ID: "42", Email: "reader@example.com"}, nil)
+	orders := make([]api.Order, 25)
+	for i := range orders {
+		orders[i] = api.Order{ID: strconv.Itoa(len(orders) - i), Status: api.OrderPaid}
+	}
+	mockService.On("GetOrderHistory", mock.Anything, "42").Return(orders, nil)
+
+	_, resp := do(t, mockService, "valid-token", `{ me { orders { id } } }`)
+
+	require.Empty(t, resp.Errors)
+	var data struct {
+		Me struct {
+			Orders []struct{ ID string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.Len(t, data.Me.Orders, 20)
+	assert.Equal(t, "25", data.Me.Orders[0].ID, "the newest orders are kept")
+}
+
+func Test_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		serviceError error
+		wantData     string
+		wantCodes    []string
+		wantMessage  string
+	}{
+		{
+			name:     "missing_book_is_null",
+			query:    `{ book(id: "7") { title } }`,
+			wantData: `{"book":null}`,
+			// ErrBookNotFound is not an error for a nullable lookup.
+			serviceError: api.ErrBookNotFound,
+		},
+		{
+			name:        "invalid_id",
+			query:       `{ book(id: "abc") { title } }`,
+			wantData:    `{"book":null}`,
+			wantCodes:   []string{"validation_failed"},
+			wantMessage: "id must be a positive integer",
+		},
+		{
+			name:         "internal_errors_are_hidden",
+			query:        `{ book(id: "7") { title } }`,
+			serviceError: errors.New("connection refused"),
+			wantData:     `{"book":null}`,
+			wantCodes:    []string{"internal"},
+			wantMessage:  "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("GetBookByID", mock.Anything, "7").Return(api.Book{}, tt.serviceError).Maybe()
+
+			_, resp := do(t, mockService, "", tt.query)
+
+			assert.JSONEq(t, tt.wantData, string(resp.Data))
+			assert.Equal(t, tt.wantCodes, errorCodes(resp))
+			if tt.wantMessage != "" {
+				assert.Equal(t, tt.wantMessage, resp.Errors[0].Message)
+				assert.Equal(t, "req-1", resp.Errors[0].Extensions["requestId"])
+			}
+		})
+	}
+}
+
+func Test_ComplexityLimit(t *testing.T) {
+	mockService := new(mocks.Service)
+
+	_, resp := do(t, mockService, "", `{
+		a: books(first: 100) { nodes { id title author description price stock createdAt } }
+		b: books(first: 100) { nodes { id title author description price stock createdAt } }
+		c: books(first: 100) { nodes { id title author description price stock createdAt } }
+	}`)
+
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "operation has complexity")
+	mockService.AssertNotCalled(t, "GetAllBooks", mock.Anything, mock.Anything)
+}
+
+func Test_InvalidToken(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", mock.Anything, "expired").Return(api.Principal{}, api.ErrInvalidSession)
+
+	code, _ := do(t, mockService, "expired", `{ me { id } }`)
+
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,58 @@
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+	"context"
+)
+
+type accountServer struct {
+	bookstorev1.UnimplementedAccountServiceServer
+	app     *application.Application
+	service api.Service
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, req *bookstorev1.CreateAccountRequest) (*bookstorev1.CreateAccountResponse, error) {
+	account := api.AccountRequest{Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := api.Validate(account); err != nil {
+		return nil, err
+	}
+	if err := s.service.CreateAccount(ctx, account.Email, account.Password); err != nil {
+		return nil, err
+	}
+	return &bookstorev1.CreateAccountResponse{}, nil
+}
+
+func (s *accountServer) CreateSession(ctx context.Context, req *bookstorev1.CreateSessionRequest) (*bookstorev1.CreateSessionResponse, error) {
+	credentials := api.LoginRequest{Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := api.Validate(credentials); err != nil {
+		return nil, err
+	}
+	session, err := s.service.CreateSession(ctx, credentials.Email, credentials.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.CreateSessionResponse{
+		Token:      session.Token,
+		UserId:     session.UserID,
+		ExpireTime: timestamp(session.ExpiresAt),
+	}, nil
+}
+
+func (s *accountServer) LookupUser(ctx context.Context, req *bookstorev1.LookupUserRequest) (*bookstorev1.LookupUserResponse, error) {
+	lookup := struct {
+		Email string `json:"email" binding:"required,email"`
+	}{req.GetEmail()}
+	if err := api.Validate(lookup); err != nil {
+		return nil, err
+	}
+	userID, err := s.service.GetUserIDByEmail(ctx, lookup.Email)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, api.ErrUserNotFound
+	}
+	return &bookstorev1.LookupUserResponse{UserId: userID}, nil
+}
@@ -0,0 +1,123 @@
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+	"context"
+)
+
+type catalogServer struct {
+	bookstorev1.UnimplementedCatalogServiceServer
+	app     *application.Application
+	service api.Service
+}
+
+func (s *catalogServer) ListBooks(ctx context.Context, req *bookstorev1.ListBooksRequest) (*bookstorev1.ListBooksResponse, error) {
+	query, err := bookQuery(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = int(req.GetPageSize())
+	query.Cursor = req.GetPageToken()
+	page, err := s.service.GetAllBooks(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	resp := &bookstorev1.ListBooksResponse{
+		Books:         make([]*bookstorev1.Book, len(page.Items)),
+		NextPageToken: page.NextCursor,
+		TotalSize:     int32(page.Total),
+	}
+	for i, book := range page.Items {
+		resp.Books[i] = toProtoBook(book)
+	}
+	return resp, nil
+}
+
+// StreamBooks follows the listing cursors itself, fetching the largest pages
+// the service allows.
+func (s *catalogServer) StreamBooks(req *bookstorev1.StreamBooksRequest, stream bookstorev1.CatalogService_StreamBooksServer) error {
+	query, err := bookQuery(req.GetFilter())
+	if err != nil {
+		return err
+	}
+	query.Limit = api.MaxBookPageSize
+	for {
+		page, err := s.service.GetAllBooks(stream.Context(), query)
+		if err != nil {
+			return err
+		}
+		for _, book := range page.Items {
+			if err := stream.Send(&bookstorev1.StreamBooksResponse{Book: toProtoBook(book)}); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (s *catalogServer) SearchBooks(ctx context.Context, req *bookstorev1.SearchBooksRequest) (*bookstorev1.SearchBooksResponse, error) {
+	results, err := s.service.SearchBooks(ctx, api.BookSearch{Query: req.GetQuery(), Limit: int(req.GetLimit())})
+	if err != nil {
+		return nil, err
+	}
+	resp := &bookstorev1.SearchBooksResponse{Results: make([]*bookstorev1.BookSearchResult, len(results))}
+	for i, r := range results {
+		resp.Results[i] = &bookstorev1.BookSearchResult{Book: toProtoBook(r.Book), Rank: r.Rank, Headline: r.Headline}
+	}
+	return resp, nil
+}
+
+func (s *catalogServer) GetBook(ctx context.Context, req *bookstorev1.GetBookRequest) (*bookstorev1.GetBookResponse, error) {
+	book, err := s.service.GetBookByID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.GetBookResponse{Book: toProtoBook(book)}, nil
+}
+
+func (s *catalogServer) CreateBook(ctx context.Context, req *bookstorev1.CreateBookRequest) (*bookstorev1.CreateBookResponse, error) {
+	book, err := s.service.CreateBook(ctx, api.Book{
+		Title:       req.GetTitle(),
+		Author:      req.GetAuthor(),
+		Description: req.GetDescription(),
+		Price:       api.Money(req.GetPriceCents()),
+		Stock:       int(req.GetStock()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.CreateBookResponse{Book: toProtoBook(book)}, nil
+}
+
+func (s *catalogServer) UpdateBook(ctx context.Context, req *bookstorev1.UpdateBookRequest) (*bookstorev1.UpdateBookResponse, error) {
+	patch := api.BookPatch{
+		Title:       req.Title,
+		Author:      req.Author,
+		Description: req.Description,
+	}
+	if req.PriceCents != nil {
+		price := api.Money(req.GetPriceCents())
+		patch.Price = &price
+	}
+	if req.Stock != nil {
+		stock := int(req.GetStock())
+		patch.Stock = &stock
+	}
+	book, err := s.service.PatchBook(ctx, req.GetId(), patch)
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.UpdateBookResponse{Book: toProtoBook(book)}, nil
+}
+
+func (s *catalogServer) DeleteBook(ctx context.Context, req *bookstorev1.DeleteBookRequest) (*bookstorev1.DeleteBookResponse, error) {
+	if err := s.service.DeleteBook(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &bookstorev1.DeleteBookResponse{}, nil
+}
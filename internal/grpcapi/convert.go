@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var bookSorts = map[bookstorev1.BookSort]api.BookSort{
+	bookstorev1.BookSort_BOOK_SORT_UNSPECIFIED: api.SortByTitle,
+	bookstorev1.BookSort_BOOK_SORT_TITLE:       api.SortByTitle,
+	bookstorev1.BookSort_BOOK_SORT_AUTHOR:      api.SortByAuthor,
+	bookstorev1.BookSort_BOOK_SORT_PRICE:       api.SortByPrice,
+	bookstorev1.BookSort_BOOK_SORT_CREATED:     api.SortByCreated,
+}
+
+var orderStatuses = map[api.OrderStatus]bookstorev1.OrderStatus{
+	api.OrderPending:   bookstorev1.OrderStatus_ORDER_STATUS_PENDING,
+	api.OrderPaid:      bookstorev1.OrderStatus_ORDER_STATUS_PAID,
+	api.OrderShipped:   bookstorev1.OrderStatus_ORDER_STATUS_SHIPPED,
+	api.OrderDelivered: bookstorev1.OrderStatus_ORDER_STATUS_DELIVERED,
+	api.OrderCancelled: bookstorev1.OrderStatus_ORDER_STATUS_CANCELLED,
+	api.OrderRefunded:  bookstorev1.OrderStatus_ORDER_STATUS_REFUNDED,
+}
+
+// fromProtoStatus maps ORDER_STATUS_UNSPECIFIED and unknown values to "",
+// which the service rejects as an unknown status.
+func fromProtoStatus(s bookstorev1.OrderStatus) api.OrderStatus {
+	for status, p := range orderStatuses {
+		if p == s {
+			return status
+		}
+	}
+	return ""
+}
+
+func bookQuery(filter *bookstorev1.BookFilter) (api.BookQuery, error) {
+	if filter == nil {
+		filter = &bookstorev1.BookFilter{}
+	}
+	sort, ok := bookSorts[filter.GetSort()]
+	if !ok {
+		return api.BookQuery{}, api.ValidationError{Field: "filter.sort", Message: "is not a known sort"}
+	}
+	query := api.BookQuery{
+		Sort:   sort,
+		Desc:   filter.GetDescending(),
+		Author: filter.GetAuthor(),
+	}
+	if filter.MinPriceCents != nil {
+		price := api.Money(filter.GetMinPriceCents())
+		query.MinPrice = &price
+	}
+	if filter.MaxPriceCents != nil {
+		price := api.Money(filter.GetMaxPriceCents())
+		query.MaxPrice = &price
+	}
+	if filter.InStock != nil {
+		inStock := filter.GetInStock()
+		query.InStock = &inStock
+	}
+	return query, nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toProtoBook(b api.Book) *bookstorev1.Book {
+	return &bookstorev1.Book{
+		Id:          b.ID,
+		Title:       b.Title,
+		Author:      b.Author,
+		Description: b.Description,
+		PriceCents:  int64(b.Price),
+		Stock:       int32(b.Stock),
+		CreateTime:  timestamp(b.CreatedAt),
+	}
+}
+
+func toProtoOrder(o api.Order) *bookstorev1.Order {
+	order := &bookstorev1.Order{
+		Id:            o.ID,
+		UserId:        o.UserID,
+		Status:        orderStatuses[o.Status],
+		Items:         make([]*bookstorev1.OrderItem, len(o.Items)),
+		SubtotalCents: int64(o.Subtotal),
+		TaxCents:      int64(o.Tax),
+		TotalCents:    int64(o.Total),
+		CreateTime:    timestamp(o.CreatedAt),
+	}
+	for i, item := range o.Items {
+		order.Items[i] = &bookstorev1.OrderItem{
+			BookId:         item.BookID,
+			Quantity:       int32(item.Quantity),
+			Title:          item.Title,
+			UnitPriceCents: int64(item.UnitPrice),
+			LineTotalCents: int64(item.LineTotal),
+		}
+	}
+	for _, change := range o.History {
+		order.History = append(order.History, &bookstorev1.OrderStatusChange{
+			From:       orderStatuses[change.From],
+			To:         orderStatuses[change.To],
+			ChangedBy:  change.ChangedBy,
+			ChangeTime: timestamp(change.ChangedAt),
+		})
+	}
+	return order
+}
@@ -0,0 +1,114 @@
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/apperror"
+	"bookstore/internal/logging"
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain of the codes defined by apperror.
+const errorDomain = "bookstore"
+
+var statusCodes = map[apperror.Kind]codes.Code{
+	apperror.KindBadRequest:   codes.InvalidArgument,
+	apperror.KindValidation:   codes.InvalidArgument,
+	apperror.KindNotFound:     codes.NotFound,
+	apperror.KindConflict:     codes.FailedPrecondition,
+	apperror.KindUnauthorized: codes.Unauthenticated,
+	apperror.KindForbidden:    codes.PermissionDenied,
+	apperror.KindInternal:     codes.Internal,
+}
+
+// unaryErrors and streamErrors turn the errors returned by the servers and
+// the authenticator into gRPC statuses, like api.ErrorHandler does for HTTP.
+func unaryErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+func streamErrors(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatus(ss.Context(), info.FullMethod, err)
+	}
+	return nil
+}
+
+// toStatus classifies err with apperror. The status carries an ErrorInfo
+// with the error code and request ID and, for validation errors, a
+// BadRequest listing the rejected fields. Internal errors are logged and
+// never reach clients.
+func toStatus(ctx context.Context, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	appErr := apperror.From(err)
+	code, ok := statusCodes[appErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+	if code == codes.Internal {
+		logging.FromContext(ctx).ErrorContext(ctx, "Error handling call", "method", method, "error", err)
+	}
+
+	st := status.New(code, appErr.Message)
+	info := &errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain}
+	if id := logging.RequestID(ctx); id != "" {
+		info.Metadata = map[string]string{"requestId": id}
+	}
+	var detailed *status.Status
+	if violations := fieldViolations(err); len(violations) > 0 {
+		detailed, err = st.WithDetails(info, &errdetails.BadRequest{FieldViolations: violations})
+	} else {
+		detailed, err = st.WithDetails(info)
+	}
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var errs api.ValidationErrors
+	var single api.ValidationError
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &single):
+		errs = api.ValidationErrors{single}
+	default:
+		return nil
+	}
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	for i, e := range errs {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: protoFieldPath(e.Field), Description: e.Message}
+	}
+	return violations
+}
+
+// protoFieldPath renames the JSON field names used by api validation errors
+// to their protobuf names: "items[0].bookId" becomes "items[0].book_id".
+func protoFieldPath(field string) string {
+	var b strings.Builder
+	for _, r := range field {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package grpcapi
+
+import (
+	"bookstore/internal/logging"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key of the request ID, read from the request
+// and sent back in the response header.
+var requestIDKey = strings.ToLower(logging.RequestIDHeader)
+
+// requestLogger is the gRPC counterpart of logging.Middleware: it assigns
+// each call a request ID and logs the call once it completes.
+type requestLogger struct {
+	logger *slog.Logger
+}
+
+func (l requestLogger) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = l.begin(ctx)
+	resp, err := handler(ctx, req)
+	l.end(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (l requestLogger) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := l.begin(ss.Context())
+	err := handler(srv, contextStream{ServerStream: ss, ctx: ctx})
+	l.end(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func (l requestLogger) begin(ctx context.Context) context.Context {
+	var sent string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(values) > 0 {
+		sent = values[0]
+	}
+	id := logging.RequestIDFrom(sent)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return logging.NewContext(ctx, l.logger, id)
+}
+
+func (l requestLogger) end(ctx context.Context, method string, start time.Time, err error) {
+	l.logger.LogAttrs(ctx, slog.LevelInfo, "request",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+}
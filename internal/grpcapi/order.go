@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+	"context"
+)
+
+type orderServer struct {
+	bookstorev1.UnimplementedOrderServiceServer
+	app     *application.Application
+	service api.Service
+}
+
+func (s *orderServer) PlaceOrder(ctx context.Context, req *bookstorev1.PlaceOrderRequest) (*bookstorev1.PlaceOrderResponse, error) {
+	request := api.OrderRequest{Items: make([]api.OrderItemRequest, len(req.GetItems()))}
+	books := make([]api.BookOrder, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		request.Items[i] = api.OrderItemRequest{BookID: item.GetBookId(), Quantity: int(item.GetQuantity())}
+		books[i] = api.BookOrder{BookID: item.GetBookId(), Quantity: int(item.GetQuantity())}
+	}
+	if err := api.Validate(request); err != nil {
+		return nil, err
+	}
+	order, err := s.service.PlaceOrder(ctx, principalFrom(ctx).UserID, books)
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.PlaceOrderResponse{Order: toProtoOrder(order)}, nil
+}
+
+func (s *orderServer) ListOrders(req *bookstorev1.ListOrdersRequest, stream bookstorev1.OrderService_ListOrdersServer) error {
+	ctx := stream.Context()
+	orders, err := s.service.GetOrderHistory(ctx, principalFrom(ctx).UserID)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := stream.Send(&bookstorev1.ListOrdersResponse{Order: toProtoOrder(order)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *orderServer) CancelOrder(ctx context.Context, req *bookstorev1.CancelOrderRequest) (*bookstorev1.CancelOrderResponse, error) {
+	order, err := s.service.CancelOrder(ctx, principalFrom(ctx).UserID, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.CancelOrderResponse{Order: toProtoOrder(order)}, nil
+}
+
+func (s *orderServer) TransitionOrder(ctx context.Context, req *bookstorev1.TransitionOrderRequest) (*bookstorev1.TransitionOrderResponse, error) {
+	order, err := s.service.TransitionOrder(ctx, principalFrom(ctx).UserID, req.GetId(), fromProtoStatus(req.GetStatus()))
+	if err != nil {
+		return nil, err
+	}
+	return &bookstorev1.TransitionOrderResponse{Order: toProtoOrder(order)}, nil
+}
@@ -0,0 +1,31 @@
+// Package grpcapi serves api.Service over gRPC as described by
+// proto/bookstore/v1/bookstore.proto. The code in gen is generated from it
+// with `make proto`.
+package grpcapi
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/application"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer registers the catalog, account and order services on a new gRPC
+// server backed by service, normally the same instance the HTTP handlers
+// use. opts are passed on to grpc.NewServer.
+func NewServer(app *application.Application, service api.Service, opts ...grpc.ServerOption) *grpc.Server {
+	a := authenticator{service: service}
+	l := requestLogger{logger: app.Logger()}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(l.unary, unaryErrors, a.unary),
+		grpc.ChainStreamInterceptor(l.stream, streamErrors, a.stream),
+	)
+	s := grpc.NewServer(opts...)
+	bookstorev1.RegisterCatalogServiceServer(s, &catalogServer{app: app, service: service})
+	bookstorev1.RegisterAccountServiceServer(s, &accountServer{app: app, service: service})
+	bookstorev1.RegisterOrderServiceServer(s, &orderServer{app: app, service: service})
+	reflection.Register(s)
+	return s
+}
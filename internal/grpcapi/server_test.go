@@ -0,0 +1,361 @@
+package grpcapi_test
+
+import (
+	"bookstore/internal/api"
+	"bookstore/internal/api/mocks"
+	"bookstore/internal/application"
+	"bookstore/internal/grpcapi"
+	bookstorev1 "bookstore/internal/grpcapi/gen/bookstore/v1"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves service on an in-memory listener and returns a client
+// connection to it.
+func dial(t *testing.T, service api.Service) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(application.NewAppMock(), service)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// errorReason returns the code and the ErrorInfo reason of a status error.
+func errorReason(err error) (codes.Code, string) {
+	st := status.Convert(err)
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.Reason
+		}
+	}
+	return st.Code(), ""
+}
+
+func Test_GetBook(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		book         api.Book
+		serviceError error
+		wantBook     *bookstorev1.Book
+		wantCode     codes.Code
+		wantReason   string
+	}{
+		{
+			name:     "success",
+			book:     api.Book{ID: "7", Title: "Dune", Author: "Frank Herbert", Price: 999, Stock: 3, CreatedAt: createdAt},
+			wantBook: &bookstorev1.Book{Id: "7", Title: "Dune", Author: "Frank Herbert", PriceCents: 999, Stock: 3},
+			wantCode: codes.OK,
+		},
+		{
+			name:         "not_found",
+			serviceError: api.ErrBookNotFound,
+			wantCode:     codes.NotFound,
+			wantReason:   "book_not_found",
+		},
+		{
+			name:         "internal_errors_are_hidden",
+			serviceError: errors.New("connection refused"),
+			wantCode:     codes.Internal,
+			wantReason:   "internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("GetBookByID", mock.Anything, "7").Return(tt.book, tt.serviceError).Once()
+			client := bookstorev1.NewCatalogServiceClient(dial(t, mockService))
+
+			resp, err := client.GetBook(context.Background(), &bookstorev1.GetBookRequest{Id: "7"})
+
+			code, reason := errorReason(err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+			if tt.wantBook != nil {
+				assert.Equal(t, tt.wantBook.Title, resp.GetBook().GetTitle())
+				assert.Equal(t, tt.wantBook.PriceCents, resp.GetBook().GetPriceCents())
+				assert.Equal(t, createdAt, resp.GetBook().GetCreateTime().AsTime())
+			} else {
+				assert.NotContains(t, status.Convert(err).Message(), "connection refused")
+			}
+		})
+	}
+}
+
+func Test_GetBook_InvalidID(t *testing.T) {
+	// The service rejects the ID before the repository sees it.
+	service := api.NewService(application.NewAppMock(), new(mocks.Repository))
+	client := bookstorev1.NewCatalogServiceClient(dial(t, service))
+
+	_, err := client.GetBook(context.Background(), &bookstorev1.GetBookRequest{Id: "abc"})
+
+	code, reason := errorReason(err)
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, "validation_failed", reason)
+}
+
+func Test_StreamBooks(t *testing.T) {
+	mockService := new(mocks.Service)
+	first := api.BookQuery{Sort: api.SortByPrice, Desc: true, Limit: api.MaxBookPageSize}
+	second := first
+	second.Cursor = "next"
+	mockService.On("GetAllBooks", mock.Anything, first).Return(api.BookPage{
+		Items:      []api.Book{{ID: "1", Title: "Dune"}, {ID: "2", Title: "Emma"}},
+		NextCursor: "next",
+	}, nil).Once()
+	mockService.On("GetAllBooks", mock.Anything, second).Return(api.BookPage{
+		Items: []api.Book{{ID: "3", Title: "Ulysses"}},
+	}, nil).Once()
+	client := bookstorev1.NewCatalogServiceClient(dial(t, mockService))
+
+	stream, err := client.StreamBooks(context.Background(), &bookstorev1.StreamBooksRequest{
+		Filter: &bookstorev1.BookFilter{Sort: bookstorev1.BookSort_BOOK_SORT_PRICE, Descending: true},
+	})
+	require.NoError(t, err)
+	var titles []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		titles = append(titles, resp.GetBook().GetTitle())
+	}
+
+	assert.Equal(t, []string{"Dune", "Emma", "Ulysses"}, titles)
+	mockService.AssertExpectations(t)
+}
+
+func Test_PlaceOrder(t *testing.T) {
+	placed := api.Order{
+		ID: "9", UserID: "42", Status: api.OrderPending,
+		Items:    []api.BookOrder{{BookID: "1", Quantity: 2, Title: "Dune", UnitPrice: 999, LineTotal: 1998}},
+		Subtotal: 1998, Total: 1998,
+	}
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		items          []*bookstorev1.OrderItem
+		authError      error
+		wantCode       codes.Code
+		wantReason     string
+		wantViolations []string
+	}{
+		{
+			name:     "success",
+			ctx:      withToken("valid-token"),
+			items:    []*bookstorev1.OrderItem{{BookId: "1", Quantity: 2}},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "missing_token",
+			ctx:        context.Background(),
+			items:      []*bookstorev1.OrderItem{{BookId: "1", Quantity: 2}},
+			wantCode:   codes.Unauthenticated,
+			wantReason: "authentication_required",
+		},
+		{
+			name:       "invalid_items",
+			ctx:        withToken("valid-token"),
+			items:      []*bookstorev1.OrderItem{{BookId: "abc", Quantity: 2}, {BookId: "2", Quantity: 101}},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "validation_failed",
+			wantViolations: []string{
+				"items[0].book_id: must be a positive integer",
+				"items[1].quantity: must be at most 100",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, tt.authError).Maybe()
+			mockService.On("PlaceOrder", mock.Anything, "42", []api.BookOrder{{BookID: "1", Quantity: 2}}).Return(placed, nil).Maybe()
+			client := bookstorev1.NewOrderServiceClient(dial(t, mockService))
+
+			resp, err := client.PlaceOrder(tt.ctx, &bookstorev1.PlaceOrderRequest{Items: tt.items})
+
+			code, reason := errorReason(err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+			var violations []string
+			for _, d := range status.Convert(err).Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					for _, v := range br.FieldViolations {
+						violations = append(violations, v.Field+": "+v.Description)
+					}
+				}
+			}
+			assert.Equal(t, tt.wantViolations, violations)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "9", resp.GetOrder().GetId())
+				assert.Equal(t, bookstorev1.OrderStatus_ORDER_STATUS_PENDING, resp.GetOrder().GetStatus())
+				assert.Equal(t, int64(1998), resp.GetOrder().GetTotalCents())
+			}
+		})
+	}
+}
+
+func Test_TransitionOrder_RequiresPermission(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", mock.Anything, "customer-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil)
+	client := bookstorev1.NewOrderServiceClient(dial(t, mockService))
+
+	_, err := client.TransitionOrder(withToken("customer-token"), &bookstorev1.TransitionOrderRequest{
+		Id: "9", Status: bookstorev1.OrderStatus_ORDER_STATUS_SHIPPED,
+	})
+
+	code, reason := errorReason(err)
+	assert.Equal(t, codes.PermissionDenied, code)
+	assert.Equal(t, "insufficient_permissions", reason)
+	mockService.AssertNotCalled(t, "TransitionOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_ListOrders(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil)
+	mockService.On("GetOrderHistory", mock.Anything, "42").Return([]api.Order{
+		{ID: "10", Status: api.OrderShipped},
+		{ID: "9", Status: api.OrderCancelled},
+	}, nil)
+	client := bookstorev1.NewOrderServiceClient(dial(t, mockService))
+
+	stream, err := client.ListOrders(withToken("valid-token"), &bookstorev1.ListOrdersRequest{})
+	require.NoError(t, err)
+	var got []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, resp.GetOrder().GetId()+" "+resp.GetOrder().GetStatus().String())
+	}
+
+	assert.Equal(t, []string{"10 ORDER_STATUS_SHIPPED", "9 ORDER_STATUS_CANCELLED"}, got)
+}
+
+func Test_CreateAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name:     "success",
+			password: "password123",
+			wantCode: codes.OK,
+		},
+		{
+			name:       "weak_password",
+			password:   "password",
+			wantCode:   codes.InvalidArgument,
+			wantReason: "validation_failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("CreateAccount", mock.Anything, "test@example.com", tt.password).Return(nil).Maybe()
+			client := bookstorev1.NewAccountServiceClient(dial(t, mockService))
+
+			_, err := client.CreateAccount(context.Background(), &bookstorev1.CreateAccountRequest{Email: "test@example.com", Password: tt.password})
+
+			code, reason := errorReason(err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func Test_LookupUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name:     "success",
+			ctx:      withToken("valid-token"),
+			wantCode: codes.OK,
+		},
+		{
+			name:       "missing_token",
+			ctx:        context.Background(),
+			wantCode:   codes.Unauthenticated,
+			wantReason: "authentication_required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.Service)
+			mockService.On("Authenticate", mock.Anything, "valid-token").Return(api.Principal{UserID: "42", Role: api.RoleCustomer}, nil).Maybe()
+			mockService.On("GetUserIDByEmail", mock.Anything, "test@example.com").Return("7", nil).Maybe()
+			client := bookstorev1.NewAccountServiceClient(dial(t, mockService))
+
+			resp, err := client.LookupUser(tt.ctx, &bookstorev1.LookupUserRequest{Email: "test@example.com"})
+
+			code, reason := errorReason(err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "7", resp.GetUserId())
+			} else {
+				mockService.AssertNotCalled(t, "GetUserIDByEmail", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func Test_RequestID(t *testing.T) {
+	mockService := new(mocks.Service)
+	mockService.On("GetBookByID", mock.Anything, "7").Return(api.Book{}, api.ErrBookNotFound).Once()
+	client := bookstorev1.NewCatalogServiceClient(dial(t, mockService))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1")
+
+	var header metadata.MD
+	_, err := client.GetBook(ctx, &bookstorev1.GetBookRequest{Id: "7"}, grpc.Header(&header))
+
+	assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+	var info *errdetails.ErrorInfo
+	for _, d := range status.Convert(err).Details() {
+		if i, ok := d.(*errdetails.ErrorInfo); ok {
+			info = i
+		}
+	}
+	require.NotNil(t, info)
+	assert.Equal(t, "req-1", info.GetMetadata()["requestId"])
+}
@@ -0,0 +1,151 @@
+// Package logging builds the structured logger of the application and
+// carries the request ID and caller of each request through contexts, so
+// that every line logged while serving a request can be correlated.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the HTTP header, and lower-cased the gRPC metadata key,
+// that carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// New returns a logger writing to w in format ("json" or "text") at level
+// and above. Records logged with a context carrying request info get
+// request_id and user_id attributes, and trace_id and span_id when the
+// context carries a span.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Discard returns a logger that drops everything, for tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// contextHandler adds the request info and trace found on the record's
+// context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	if info := infoFrom(ctx); info != nil {
+		r.AddAttrs(slog.String("request_id", info.id))
+		if info.userID != "" {
+			r.AddAttrs(slog.String("user_id", info.userID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// requestInfo is shared by pointer so that authentication, which runs after
+// the request ID is assigned, can fill in the caller for the access log.
+type requestInfo struct {
+	id     string
+	userID string
+	logger *slog.Logger
+}
+
+type infoKey struct{}
+
+func infoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(infoKey{}).(*requestInfo)
+	return info
+}
+
+// NewContext starts the request identified by id, to be logged with logger.
+func NewContext(ctx context.Context, logger *slog.Logger, id string) context.Context {
+	return context.WithValue(ctx, infoKey{}, &requestInfo{id: id, logger: logger})
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "".
+func RequestID(ctx context.Context) string {
+	if info := infoFrom(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the authenticated caller of the request ctx belongs to.
+// It must be called before the request fans out to other goroutines.
+func SetUserID(ctx context.Context, userID string) {
+	if info := infoFrom(ctx); info != nil {
+		info.userID = userID
+	}
+}
+
+// UserID returns the caller recorded by SetUserID, or "".
+func UserID(ctx context.Context) string {
+	if info := infoFrom(ctx); info != nil {
+		return info.userID
+	}
+	return ""
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger outside of requests.
+func FromContext(ctx context.Context) *slog.Logger {
+	if info := infoFrom(ctx); info != nil && info.logger != nil {
+		return info.logger
+	}
+	return slog.Default()
+}
+
+// RequestIDFrom returns the ID the client sent when it is acceptable, or a
+// new random one. Client IDs are limited to printable ASCII so that they
+// cannot forge log lines or headers.
+func RequestIDFrom(sent string) string {
+	if sent != "" && len(sent) <= maxRequestIDLength && !strings.ContainsFunc(sent, func(r rune) bool {
+		return r < 0x21 || r > 0x7e
+	}) {
+		return sent
+	}
+	return NewRequestID()
+}
+
+// NewRequestID returns a random 128-bit ID in hex.
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,153 @@
+package logging_test
+
+import (
+	"bookstore/internal/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lines decodes the JSON log lines written to buf.
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &fields), line)
+		out = append(out, fields)
+	}
+	return out
+}
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		level   string
+		wantErr string
+	}{
+		{name: "json", format: "json", level: "info"},
+		{name: "text", format: "text", level: "debug"},
+		{name: "unknown_format", format: "xml", level: "info", wantErr: `invalid log format "xml"`},
+		{name: "unknown_level", format: "json", level: "loud", wantErr: `invalid log level "loud"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := logging.New(&bytes.Buffer{}, tt.format, tt.level)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_New_AddsRequestInfo(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "warn")
+	require.NoError(t, err)
+	ctx := logging.NewContext(context.Background(), logger, "req-1")
+	logging.SetUserID(ctx, "42")
+
+	logger.InfoContext(ctx, "below the level")
+	logger.WarnContext(ctx, "in a request")
+	logger.With("component", "test").Warn("outside a request")
+
+	got := lines(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "in a request", got[0]["msg"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	assert.Equal(t, "42", got[0]["user_id"])
+	assert.Equal(t, "test", got[1]["component"])
+	assert.NotContains(t, got[1], "request_id")
+}
+
+func Test_New_AddsTrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "info")
+	require.NoError(t, err)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.InfoContext(ctx, "traced")
+
+	got := lines(t, &buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", got[0]["span_id"])
+}
+
+func Test_RequestIDFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		sent     string
+		wantSent bool
+	}{
+		{name: "accepted", sent: "3f2c-abc_DEF.1", wantSent: true},
+		{name: "missing", sent: ""},
+		{name: "with_spaces", sent: "a b"},
+		{name: "with_newline", sent: "a\nlevel=ERROR"},
+		{name: "too_long", sent: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := logging.RequestIDFrom(tt.sent)
+
+			if tt.wantSent {
+				assert.Equal(t, tt.sent, id)
+				return
+			}
+			assert.Regexp(t, "^[0-9a-f]{32}$", id)
+		})
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "info")
+	require.NoError(t, err)
+	r := gin.New()
+	r.Use(logging.Middleware(logger))
+	r.GET("/users/:id", func(c *gin.Context) {
+		logging.SetUserID(c.Request.Context(), c.Param("id"))
+		logging.FromContext(c.Request.Context()).InfoContext(c.Request.Context(), "handling")
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/42?email=someone@example.com", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-1", w.Body.String())
+	assert.Equal(t, "req-1", w.Header().Get(logging.RequestIDHeader))
+	got := lines(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "handling", got[0]["msg"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	access := got[1]
+	assert.Equal(t, "request", access["msg"])
+	assert.Equal(t, "req-1", access["request_id"])
+	assert.Equal(t, "42", access["user_id"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/users/42", access["path"])
+	assert.Equal(t, "/users/:id", access["route"])
+	assert.Equal(t, float64(http.StatusOK), access["status"])
+	assert.Contains(t, access, "latency_ms")
+	assert.NotContains(t, buf.String(), "someone@example.com")
+}
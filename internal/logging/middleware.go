@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware assigns each request an ID, taken from the X-Request-ID header
+// when the client sent a usable one, echoes it in the response and logs the
+// request once it is served. The query string is left out of the access log
+// because it may carry personal data such as email addresses.
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := RequestIDFrom(c.GetHeader(RequestIDHeader))
+		c.Header(RequestIDHeader, id)
+		ctx := NewContext(c.Request.Context(), logger, id)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", c.Writer.Status()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
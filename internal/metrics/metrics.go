@@ -0,0 +1,109 @@
+// Package metrics defines the Prometheus metrics of the application. Labels
+// are limited to values from fixed sets, such as route patterns and method
+// names, so that the number of series stays bounded whatever the traffic.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bookstore"
+
+// UnmatchedRoute labels requests that matched no route, so that scans of
+// random paths do not create a series each.
+const UnmatchedRoute = "unmatched"
+
+// Metrics holds the collectors of one application. Each instance has its own
+// registry, so tests can build as many as they need.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTPRequestDuration is labelled by method, route pattern and status
+	// code.
+	HTTPRequestDuration *prometheus.HistogramVec
+	// RepositoryQueryDuration and RepositoryErrors are labelled by the
+	// Repository method.
+	RepositoryQueryDuration *prometheus.HistogramVec
+	RepositoryErrors        *prometheus.CounterVec
+
+	OrdersPlaced    prometheus.Counter
+	AccountsCreated prometheus.Counter
+	// OrderValuePlacedCents sums the totals of placed orders in cents. It is
+	// not reduced when orders are cancelled or refunded.
+	OrderValuePlacedCents prometheus.Counter
+}
+
+// New registers the application metrics together with the Go runtime and
+// process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		RepositoryQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Time taken by Repository methods.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+		RepositoryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "errors_total",
+			Help:      "Repository method calls that failed with an internal error.",
+		}, []string{"method"}),
+		OrdersPlaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_placed_total",
+			Help:      "Orders placed, directly or by checking out a cart.",
+		}),
+		AccountsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "accounts_created_total",
+			Help:      "User accounts created.",
+		}),
+		OrderValuePlacedCents: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_value_placed_cents_total",
+			Help:      "Sum of the totals of placed orders, tax included, in cents. Cancellations and refunds are not subtracted.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequestDuration,
+		m.RepositoryQueryDuration,
+		m.RepositoryErrors,
+		m.OrdersPlaced,
+		m.AccountsCreated,
+		m.OrderValuePlacedCents,
+	)
+	return m
+}
+
+// RegisterDBStats exports the statistics of a database pool, read from stats
+// on each scrape.
+func (m *Metrics) RegisterDBStats(stats func() sql.DBStats) {
+	m.registry.MustRegister(newDBStatsCollector(stats))
+}
+
+// Registry returns the registry the metrics are gathered from.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
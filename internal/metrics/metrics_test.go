@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"bookstore/internal/metrics"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Middleware_LabelsByRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/books/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/books/1"},
+		{http.MethodGet, "/books/2"},
+		{http.MethodGet, "/no/such/path"},
+		{"BREW", "/books/1"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m.HTTPRequestDuration))
+	for _, labels := range [][]string{
+		{"GET", "/books/:id", "204"},
+		{"GET", metrics.UnmatchedRoute, "404"},
+		{"other", metrics.UnmatchedRoute, "404"},
+	} {
+		_, err := m.HTTPRequestDuration.GetMetricWithLabelValues(labels...)
+		require.NoError(t, err)
+	}
+}
+
+func Test_Handler_ExportsDBStats(t *testing.T) {
+	m := metrics.New()
+	m.RegisterDBStats(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDuration: 1500 * time.Millisecond}
+	})
+	m.OrdersPlaced.Inc()
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	for _, line := range []string{
+		"bookstore_db_max_open_connections 25",
+		"bookstore_db_open_connections 3",
+		"bookstore_db_in_use_connections 1",
+		"bookstore_db_idle_connections 2",
+		"bookstore_db_wait_count_total 4",
+		"bookstore_db_wait_duration_seconds_total 1.5",
+		"bookstore_orders_placed_total 1",
+		"go_goroutines ",
+	} {
+		assert.True(t, strings.Contains(string(body), "\n"+line), "missing %q", line)
+	}
+}
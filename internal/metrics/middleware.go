@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware records the duration of every request under the route pattern
+// it matched, such as /api/v1/books/:id, rather than its path.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = UnmatchedRoute
+		}
+		m.HTTPRequestDuration.
+			WithLabelValues(method(c.Request.Method), route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// method folds non-standard request methods, which clients may choose freely,
+// into "other".
+func method(m string) string {
+	if knownMethods[m] {
+		return m
+	}
+	return "other"
+}
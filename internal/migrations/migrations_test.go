@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load_Embedded(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.NotEmpty(t, m.Up, "version %d", m.Version)
+		assert.NotEmpty(t, m.Down, "version %d", m.Version)
+		if i > 0 {
+			assert.Greater(t, m.Version, migrations[i-1].Version)
+		}
+	}
+}
+
+func Test_Load(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      bool
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"sql/0002_b.up.sql":   {Data: []byte("b up")},
+				"sql/0002_b.down.sql": {Data: []byte("b down")},
+				"sql/0001_a.up.sql":   {Data: []byte("a up")},
+				"sql/0001_a.down.sql": {Data: []byte("a down")},
+			},
+			wantVersions: []int64{1, 2},
+		},
+		{
+			name: "missing down script",
+			files: fstest.MapFS{
+				"sql/0001_a.up.sql": {Data: []byte("a up")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"sql/first.sql": {Data: []byte("a up")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"sql/0001_a.up.sql":   {Data: []byte("a up")},
+				"sql/0001_b.down.sql": {Data: []byte("b down")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files, "sql")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tt.wantVersions, versions)
+		})
+	}
+}
+
+// Test_Check_ReadOnly needs a postgres:// URL in TEST_DATABASE_URL, like the
+// repository tests.
+func Test_Check_ReadOnly(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("migrations_%d", time.Now().UnixNano())
+	_, err = admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE") })
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	db, err := sql.Open("postgres", u.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	m, err := New(db)
+	require.NoError(t, err)
+
+	err = m.Check(ctx)
+
+	assert.EqualError(t, err, fmt.Sprintf("schema is at version 0, expected %d", m.Latest()))
+	var exists bool
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists))
+	assert.False(t, exists, "Check must not create schema_migrations")
+
+	require.NoError(t, m.Up(ctx))
+	assert.NoError(t, m.Check(ctx))
+}
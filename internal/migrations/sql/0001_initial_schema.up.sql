@@ -0,0 +1,28 @@
+CREATE TABLE users (
+    id       BIGSERIAL PRIMARY KEY,
+    email    TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+CREATE TABLE books (
+    id          BIGSERIAL PRIMARY KEY,
+    title       TEXT NOT NULL,
+    author      TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price       NUMERIC(10, 2) NOT NULL CHECK (price >= 0)
+);
+
+CREATE TABLE orders (
+    id      BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id)
+);
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+
+CREATE TABLE order_items (
+    order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    book_id  BIGINT NOT NULL REFERENCES books (id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX order_items_order_id_idx ON order_items (order_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('customer', 'staff', 'admin'));
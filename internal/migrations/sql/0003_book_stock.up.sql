@@ -0,0 +1,6 @@
+-- Books that exist when this runs start with no stock: the previous schema
+-- did not track inventory, so there is nothing to backfill from. Set the
+-- real counts (e.g. with PATCH /api/v1/books/{id}) before opening the shop,
+-- or every existing book is reported out of stock and cannot be ordered.
+ALTER TABLE books
+    ADD COLUMN stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0);
@@ -0,0 +1,13 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS subtotal_cents,
+    DROP COLUMN IF EXISTS tax_cents,
+    DROP COLUMN IF EXISTS total_cents;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_price_cents;
+
+ALTER TABLE books ADD COLUMN price NUMERIC(10, 2);
+UPDATE books SET price = price_cents / 100.0;
+ALTER TABLE books
+    ALTER COLUMN price SET NOT NULL,
+    ADD CONSTRAINT books_price_check CHECK (price >= 0),
+    DROP COLUMN price_cents;
@@ -0,0 +1,30 @@
+-- Money is stored as BIGINT minor units (cents) from here on.
+ALTER TABLE books ADD COLUMN price_cents BIGINT;
+UPDATE books SET price_cents = round(price * 100);
+ALTER TABLE books
+    ALTER COLUMN price_cents SET NOT NULL,
+    ADD CONSTRAINT books_price_cents_check CHECK (price_cents >= 0),
+    DROP COLUMN price;
+
+-- Snapshot the unit price at order time. Existing rows are backfilled from
+-- the current catalog price, which is the best information available.
+ALTER TABLE order_items ADD COLUMN unit_price_cents BIGINT;
+UPDATE order_items oi SET unit_price_cents = b.price_cents
+FROM books b WHERE b.id = oi.book_id;
+ALTER TABLE order_items
+    ALTER COLUMN unit_price_cents SET NOT NULL,
+    ADD CONSTRAINT order_items_unit_price_cents_check CHECK (unit_price_cents >= 0);
+
+ALTER TABLE orders
+    ADD COLUMN subtotal_cents BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN tax_cents      BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN total_cents    BIGINT NOT NULL DEFAULT 0;
+UPDATE orders o SET
+    subtotal_cents = t.subtotal,
+    total_cents    = t.subtotal
+FROM (
+    SELECT order_id, SUM(unit_price_cents * quantity) AS subtotal
+    FROM order_items
+    GROUP BY order_id
+) t
+WHERE t.order_id = o.id;
@@ -0,0 +1,11 @@
+-- Every user has exactly one cart, so its lines are keyed by user directly.
+-- unit_price_cents is the price when the line was last confirmed and is
+-- compared with the catalog at checkout to detect price changes.
+CREATE TABLE cart_items (
+    user_id          BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    book_id          BIGINT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    quantity         INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price_cents BIGINT NOT NULL CHECK (unit_price_cents >= 0),
+    added_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, book_id)
+);
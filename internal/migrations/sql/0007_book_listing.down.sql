@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS books_lower_author_idx;
+DROP INDEX IF EXISTS books_created_at_id_idx;
+DROP INDEX IF EXISTS books_price_cents_id_idx;
+DROP INDEX IF EXISTS books_author_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,10 @@
+ALTER TABLE books
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Keyset pagination walks (sort column, id), so each sort needs a matching
+-- composite index.
+CREATE INDEX books_title_id_idx ON books (title, id);
+CREATE INDEX books_author_id_idx ON books (author, id);
+CREATE INDEX books_price_cents_id_idx ON books (price_cents, id);
+CREATE INDEX books_created_at_id_idx ON books (created_at, id);
+CREATE INDEX books_lower_author_idx ON books (lower(author));
@@ -0,0 +1,8 @@
+ALTER TABLE books
+    ADD COLUMN search tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', author), 'B') ||
+        setweight(to_tsvector('english', description), 'C')
+    ) STORED;
+
+CREATE INDEX books_search_idx ON books USING GIN (search);
@@ -0,0 +1,97 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started by the
+// gin middleware, the service and repository decorators in api and the
+// database driver wrapper, and exported to an OTLP collector or stdout.
+package tracing
+
+import (
+	"bookstore/internal/apperror"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported as service.name unless OTEL_SERVICE_NAME is set.
+const ServiceName = "bookstore"
+
+// InstrumentationName names the tracer of the application's own spans.
+const InstrumentationName = "bookstore"
+
+// Exporters accepted by NewExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Propagator reads and writes W3C trace context and baggage headers.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{}, propagation.Baggage{},
+)
+
+// NewExporter returns the exporter named by kind, or nil for ExporterNone.
+// The OTLP exporter sends to endpoint, a URL such as
+// http://collector:4318, or else to where the standard OTEL_EXPORTER_OTLP_*
+// variables say. The stdout exporter writes to w.
+func NewExporter(ctx context.Context, kind, endpoint string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("invalid trace exporter %q", kind)
+}
+
+// NewProvider returns a provider that batches spans to exporter. Requests
+// are sampled unless the caller's trace context says otherwise.
+func NewProvider(ctx context.Context, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %v", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	), nil
+}
+
+// End finishes span, recording err. Only internal errors mark the span as
+// failed; errors the caller is responsible for, such as unknown books, are
+// recorded as the error.code attribute only.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", apperror.From(err).Code))
+		if Failed(err) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// Failed reports whether err is a failure of the server rather than of the
+// request: an internal error other than the caller going away.
+func Failed(err error) bool {
+	return err != nil && apperror.KindOf(err) == apperror.KindInternal && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,94 @@
+package tracing_test
+
+import (
+	"bookstore/internal/apperror"
+	"bookstore/internal/tracing"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_NewExporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		endpoint string
+		wantNil  bool
+		wantErr  string
+	}{
+		{name: "none", kind: tracing.ExporterNone, wantNil: true},
+		{name: "stdout", kind: tracing.ExporterStdout},
+		{name: "otlp", kind: tracing.ExporterOTLP, endpoint: "http://localhost:4318"},
+		{name: "unknown", kind: "jaeger", wantErr: `invalid trace exporter "jaeger"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := tracing.NewExporter(context.Background(), tt.kind, tt.endpoint, &bytes.Buffer{})
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNil, exporter == nil)
+		})
+	}
+}
+
+func Test_NewProvider_ExportsToStdout(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := tracing.NewExporter(context.Background(), tracing.ExporterStdout, "", &buf)
+	require.NoError(t, err)
+	tp, err := tracing.NewProvider(context.Background(), exporter)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer(tracing.InstrumentationName).Start(context.Background(), "Service.PlaceOrder")
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	assert.Contains(t, buf.String(), `"Name":"Service.PlaceOrder"`)
+	assert.Contains(t, buf.String(), `"Value":"bookstore"`, "service.name")
+}
+
+func Test_End(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+		wantCode   string
+	}{
+		{name: "success", wantStatus: codes.Unset},
+		{name: "client_error", err: apperror.NotFound("book_not_found", "book not found"), wantStatus: codes.Unset, wantCode: "book_not_found"},
+		{name: "internal_error", err: errors.New("connection refused"), wantStatus: codes.Error, wantCode: "internal"},
+		{name: "cancelled", err: context.Canceled, wantStatus: codes.Unset, wantCode: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			_, span := tp.Tracer(tracing.InstrumentationName).Start(context.Background(), "op")
+
+			tracing.End(span, tt.err)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantStatus, spans[0].Status.Code)
+			assert.Equal(t, tt.wantStatus == codes.Error, tracing.Failed(tt.err))
+			if tt.wantCode != "" {
+				assert.Contains(t, spans[0].Attributes, attribute.String("error.code", tt.wantCode))
+			} else {
+				assert.Empty(t, spans[0].Attributes)
+			}
+		})
+	}
+}